@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	cli "github.com/jawher/mow.cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/sirupsen/logrus"
+	"github.com/xlab/closer"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/agent"
+)
+
+func agentCmd(c *cli.Cmd) {
+	socketPath := c.String(cli.StringOpt{
+		Name:   "S socket",
+		Desc:   "Specify path of the agent Unix socket.",
+		EnvVar: "DEXTERM_AGENT_SOCK",
+		Value:  "~/.dexterm/agent.sock",
+	})
+	agentAccounts := c.Strings(cli.StringsOpt{
+		Name: "a account",
+		Desc: "Account address or label to unlock, the default account is used if not specified.",
+	})
+
+	c.Action = func() {
+		if toBool(*appConfigMap["log.debug"]) {
+			logrus.SetLevel(logrus.TraceLevel)
+		}
+
+		configPath, _ := homedir.Expand(*configPath)
+		cfg, err := loadOrCreateConfig(configPath)
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+
+		// only config access is needed for account lookups
+		ctl := &AppController{
+			cfg:        cfg,
+			configPath: configPath,
+		}
+
+		ks, err := ctl.openKeystore()
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+
+		accounts := *agentAccounts
+		if len(accounts) == 0 {
+			accounts = []string{ctl.mustConfigValue("accounts.default")}
+		}
+
+		keyAgent := agent.New()
+
+		for _, addressOrLabel := range accounts {
+			account, err := ctl.resolveAccount(addressOrLabel)
+			if err != nil {
+				logrus.WithError(err).Fatalln("failed to find account")
+			}
+
+			fmt.Printf("Passphrase for %s: ", ctl.formatAccount(account))
+			password, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Println()
+			if err != nil {
+				logrus.WithError(err).Fatalln("failed to read passphrase")
+			}
+
+			key, ok := ks.PrivateKey(account, string(password))
+			if !ok {
+				logrus.WithField("account", account.Hex()).Fatalln("failed to decrypt keyfile, check the passphrase")
+			}
+
+			keyAgent.AddKey(key)
+			ks.UnsetKey(account, string(password))
+		}
+
+		agentSocket, _ := homedir.Expand(*socketPath)
+		l, err := agent.Listen(agentSocket)
+		if err != nil {
+			logrus.WithError(err).Fatalln("failed to listen on agent socket")
+		}
+
+		closer.Bind(func() {
+			l.Close()
+			os.Remove(agentSocket)
+			keyAgent.Wipe()
+		})
+
+		go func() {
+			if err := keyAgent.Serve(l); err != nil {
+				logrus.WithError(err).Debugln("agent stopped serving")
+			}
+		}()
+
+		logrus.WithField("socket", agentSocket).Infof("Agent is running with %d accounts, use DEXTERM_AGENT_SOCK to connect", len(accounts))
+		closer.Hold()
+	}
+}
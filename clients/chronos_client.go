@@ -0,0 +1,104 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	goahttp "goa.design/goa/v3/http"
+
+	chronosAPI "github.com/InjectiveLabs/dexterm/gen/chronos_api"
+	chronosHTTP "github.com/InjectiveLabs/dexterm/gen/http/chronos_api/client"
+)
+
+type ChronosClient struct {
+	cfg    *ChronosClientConfig
+	client *chronosAPI.Client
+}
+
+type ChronosClientConfig struct {
+	Endpoint string
+	Timeout  time.Duration
+	Debug    bool
+}
+
+func (c *ChronosClientConfig) check() *ChronosClientConfig {
+	if c.Timeout == 0 {
+		c.Timeout = 30 * time.Second
+	}
+
+	return c
+}
+
+func NewChronosClient(cfg *ChronosClientConfig) (*ChronosClient, error) {
+	u, err := url.ParseRequestURI(cfg.Endpoint)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse endpoint URL")
+		return nil, err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		err = errors.New("endpoint must have http:// or https:// scheme")
+		return nil, err
+	}
+
+	cli := &ChronosClient{
+		cfg:    cfg.check(),
+		client: newChronosClient(u.Scheme, u.Host, cfg.Timeout, cfg.Debug),
+	}
+
+	return cli, nil
+}
+
+// FuturesMarketSummary returns price summary of a futures market for the given resolution, e.g. "24h".
+func (c *ChronosClient) FuturesMarketSummary(
+	ctx context.Context,
+	marketID string,
+	resolution string,
+) (*chronosAPI.FuturesMarketSummaryResponse, error) {
+	if c.client == nil {
+		return nil, ErrClientUnavailable
+	}
+
+	res, err := c.client.FuturesMarketSummary(ctx, &chronosAPI.FuturesMarketSummaryPayload{
+		MarketID:   marketID,
+		Resolution: resolution,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "failed to get futures market summary")
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func newChronosClient(scheme, host string, timeout time.Duration, debug bool) *chronosAPI.Client {
+	var doer goahttp.Doer
+
+	doer = &http.Client{
+		Timeout: timeout,
+	}
+
+	if debug {
+		doer = goahttp.NewDebugDoer(doer)
+	}
+
+	c := chronosHTTP.NewClient(
+		scheme,
+		host,
+		doer,
+		goahttp.RequestEncoder,
+		goahttp.ResponseDecoder,
+		debug,
+	)
+
+	return chronosAPI.NewClient(
+		c.SymbolInfo(),
+		c.History(),
+		c.FillsHistory(),
+		c.MarketSummary(),
+		c.FuturesHistory(),
+		c.FuturesFillsHistory(),
+		c.FuturesMarketSummary(),
+	)
+}
@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"net/http"
@@ -9,16 +10,18 @@ import (
 	"time"
 
 	zeroex "github.com/InjectiveLabs/zeroex-go"
-	//"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 	goahttp "goa.design/goa/v3/http"
 	//goa "goa.design/goa/v3/pkg"
 
-	sdaHTTP "github.com/InjectiveLabs/dexterm/gen/http/derivatives_api/client"
 	sdaAPI "github.com/InjectiveLabs/dexterm/gen/derivatives_api"
-	//restAPI "github.com/InjectiveLabs/dexterm/gen/rest_api"
+	sdaHTTP "github.com/InjectiveLabs/dexterm/gen/http/derivatives_api/client"
+	restAPI "github.com/InjectiveLabs/dexterm/gen/rest_api"
 )
 
+const ordersPerPage = 100
+
 type SDAClient struct {
 	cfg        *SDAClientConfig
 	client     *sdaAPI.Client
@@ -83,7 +86,7 @@ func (c *SDAClient) PostOrder(
 
 		MakerAssetData:    "0x" + hex.EncodeToString(order.MakerAssetData),
 		TakerAssetData:    "0x" + hex.EncodeToString(order.TakerAssetData),
-		MakerFeeAssetData: "0x" + hex.EncodeToString(order.MakerFeeAssetData) ,
+		MakerFeeAssetData: "0x" + hex.EncodeToString(order.MakerFeeAssetData),
 		TakerFeeAssetData: "0x" + hex.EncodeToString(order.TakerFeeAssetData),
 		Signature:         "0x" + hex.EncodeToString(order.Signature),
 	}
@@ -93,36 +96,89 @@ func (c *SDAClient) PostOrder(
 	return orderHash.String(), err
 }
 
-//func (c *SDAClient) Orderbook(
-//	ctx context.Context,
-//	pairName string,
-//) (bids, asks []*sdaAPI.DerivativeOrderRecord, err error) {
-//	if c.client == nil {
-//		err = ErrClientUnavailable
-//		return
-//	}
-//
-//	var pair *restAPI.TradePair
-//
-//	if pair, err = c.getTradePairByName(ctx, pairName); err != nil {
-//		return
-//	} else if pair == nil {
-//		err = errors.New("trade pair not found")
-//		return
-//	}
-//
-//	res, err := c.client.Orderbook(ctx, &sraAPI.OrderbookPayload{
-//		BaseAssetData:  pair.MakerAssetData,
-//		QuoteAssetData: pair.TakerAssetData,
-//	})
-//	if err != nil {
-//		err = errors.Wrap(err, "failed to get orderbook")
-//		return
-//	}
-//
-//	return res.Bids.Records, res.Asks.Records, nil
-//}
+// Orders lists all derivative orders of the market, walking through all pages of the result.
+func (c *SDAClient) Orders(
+	ctx context.Context,
+	marketID string,
+) ([]*sdaAPI.DerivativeOrderRecord, error) {
+	if c.client == nil {
+		return nil, ErrClientUnavailable
+	}
+
+	var records []*sdaAPI.DerivativeOrderRecord
+	for page := 1; ; page++ {
+		res, err := c.client.Orders(ctx, &sdaAPI.OrdersPayload{
+			Page:           page,
+			PerPage:        ordersPerPage,
+			TakerAssetData: &marketID,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "failed to get derivative orders")
+			return nil, err
+		}
+
+		records = append(records, res.Records...)
+		if len(res.Records) == 0 || len(records) >= res.Total {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+// Orderbook returns derivative orders of the market split into bids (longs) and asks (shorts).
+func (c *SDAClient) Orderbook(
+	ctx context.Context,
+	marketName string,
+) (bids, asks []*sdaAPI.DerivativeOrderRecord, err error) {
+	if c.client == nil {
+		err = ErrClientUnavailable
+		return
+	}
+
+	var market *restAPI.DerivativeMarket
+
+	if market, err = c.getMarketByTicker(ctx, marketName); err != nil {
+		return
+	} else if market == nil {
+		err = errors.New("derivatives market not found")
+		return
+	}
+
+	records, err := c.Orders(ctx, market.MarketID)
+	if err != nil {
+		return
+	}
+
+	marketID := common.HexToHash(market.MarketID)
+	for _, record := range records {
+		makerAssetData := common.FromHex(record.DerivativeOrder.MakerAssetData)
+		if bytes.HasPrefix(makerAssetData, marketID.Bytes()) {
+			// long orders have market ID as maker asset data
+			bids = append(bids, record)
+			continue
+		}
+
+		asks = append(asks, record)
+	}
+
+	return bids, asks, nil
+}
+
+func (c *SDAClient) getMarketByTicker(ctx context.Context, ticker string) (*restAPI.DerivativeMarket, error) {
+	markets, err := c.restClient.DerivativeMarkets(ctx)
+	if err != nil {
+		return nil, err
+	}
 
+	for _, market := range markets {
+		if market.Ticker == ticker {
+			return market, nil
+		}
+	}
+
+	return nil, nil
+}
 
 func newSDAClient(scheme, host string, timeout time.Duration, debug bool) *sdaAPI.Client {
 	var doer goahttp.Doer
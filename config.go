@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	cli "github.com/jawher/mow.cli"
 	toml "github.com/pelletier/go-toml"
@@ -19,6 +20,13 @@ var configPath = app.String(cli.StringOpt{
 	Value:  "~/.dexterm/config.toml",
 })
 
+var insecureDevAccount = app.Bool(cli.BoolOpt{
+	Name:   "insecure-dev-account",
+	Desc:   "Generate the default account with a well-known passphrase if keystore is empty. Meant for devnet, never use it with real funds.",
+	EnvVar: "DEXTERM_INSECURE_DEV_ACCOUNT",
+	Value:  false,
+})
+
 var (
 	logDebugSet bool
 	logDebugOpt = cli.StringOpt{
@@ -41,6 +49,17 @@ var (
 	}
 )
 
+var (
+	chronosEndpointSet bool
+	chronosEndpointOpt = cli.StringOpt{
+		Name:      "chronos-endpoint",
+		Desc:      "Specify Chronos API endpoint for market history, relayer endpoint is used if empty.",
+		EnvVar:    "DEXTERM_CHRONOS_ENDPOINT",
+		Value:     "",
+		SetByUser: &chronosEndpointSet,
+	}
+)
+
 var (
 	accountsKeystoreSet bool
 	accountsKeystoreOpt = cli.StringOpt{
@@ -63,13 +82,111 @@ var (
 	}
 )
 
+var (
+	accountsAgentSet bool
+	accountsAgentOpt = cli.StringOpt{
+		Name:      "agent-socket",
+		Desc:      "Specify socket of a running dexterm agent to sign with, instead of the keystore.",
+		EnvVar:    "DEXTERM_AGENT_SOCK",
+		Value:     "",
+		SetByUser: &accountsAgentSet,
+	}
+)
+
+var (
+	accountsSignerSet bool
+	accountsSignerOpt = cli.StringOpt{
+		Name:      "signer",
+		Desc:      "Specify an external signer to sign with, instead of the keystore. E.g. clef:http://localhost:8550 or clef:~/.clef/clef.ipc",
+		EnvVar:    "DEXTERM_SIGNER",
+		Value:     "",
+		SetByUser: &accountsSignerSet,
+	}
+)
+
+var (
+	monitorIntervalSet bool
+	monitorIntervalOpt = cli.StringOpt{
+		Name:      "monitor-interval",
+		Desc:      "Specify how often the liquidation risk monitor checks open positions.",
+		EnvVar:    "DEXTERM_MONITOR_INTERVAL",
+		Value:     "30s",
+		SetByUser: &monitorIntervalSet,
+	}
+)
+
+var (
+	monitorMarginRatioAlertSet bool
+	monitorMarginRatioAlertOpt = cli.StringOpt{
+		Name:      "monitor-margin-ratio",
+		Desc:      "Alert when position margin falls below this multiple of the minimum margin.",
+		EnvVar:    "DEXTERM_MONITOR_MARGIN_RATIO",
+		Value:     "1.5",
+		SetByUser: &monitorMarginRatioAlertSet,
+	}
+)
+
+var (
+	monitorLiquidationDistanceAlertSet bool
+	monitorLiquidationDistanceAlertOpt = cli.StringOpt{
+		Name:      "monitor-liquidation-distance",
+		Desc:      "Alert when oracle price is within this percent of the liquidation price.",
+		EnvVar:    "DEXTERM_MONITOR_LIQUIDATION_DISTANCE",
+		Value:     "10",
+		SetByUser: &monitorLiquidationDistanceAlertSet,
+	}
+)
+
+var (
+	monitorAutoReduceSet bool
+	monitorAutoReduceOpt = cli.StringOpt{
+		Name:      "monitor-auto-reduce",
+		Desc:      "Allow the monitor to reduce risky positions with market close orders.",
+		EnvVar:    "DEXTERM_MONITOR_AUTO_REDUCE",
+		Value:     "false",
+		SetByUser: &monitorAutoReduceSet,
+	}
+)
+
+var (
+	monitorAutoReduceRatioSet bool
+	monitorAutoReduceRatioOpt = cli.StringOpt{
+		Name:      "monitor-auto-reduce-ratio",
+		Desc:      "Reduce a position when its margin falls below this multiple of the minimum margin.",
+		EnvVar:    "DEXTERM_MONITOR_AUTO_REDUCE_RATIO",
+		Value:     "1.2",
+		SetByUser: &monitorAutoReduceRatioSet,
+	}
+)
+
+var (
+	monitorAutoReducePercentSet bool
+	monitorAutoReducePercentOpt = cli.StringOpt{
+		Name:      "monitor-auto-reduce-percent",
+		Desc:      "Specify the percent of a position to close when reducing it.",
+		EnvVar:    "DEXTERM_MONITOR_AUTO_REDUCE_PERCENT",
+		Value:     "50",
+		SetByUser: &monitorAutoReducePercentSet,
+	}
+)
+
 var appConfigMap = map[string]*string{
 	"log.debug": app.String(logDebugOpt),
 
 	"relayer.endpoint": app.String(relayerEndpointOpt),
+	"chronos.endpoint": app.String(chronosEndpointOpt),
 
 	"accounts.keystore": app.String(accountsKeystoreOpt),
 	"accounts.default":  app.String(accountsDefaultOpt),
+	"accounts.agent":    app.String(accountsAgentOpt),
+	"accounts.signer":   app.String(accountsSignerOpt),
+
+	"monitor.interval":                   app.String(monitorIntervalOpt),
+	"monitor.margin_ratio_alert":         app.String(monitorMarginRatioAlertOpt),
+	"monitor.liquidation_distance_alert": app.String(monitorLiquidationDistanceAlertOpt),
+	"monitor.auto_reduce":                app.String(monitorAutoReduceOpt),
+	"monitor.auto_reduce_ratio":          app.String(monitorAutoReduceRatioOpt),
+	"monitor.auto_reduce_percent":        app.String(monitorAutoReducePercentOpt),
 
 	"networks.allow_gas_oracles": app.String(networksAllowGasOraclesOpt),
 	"networks.default":           app.String(networksDefaultOpt),
@@ -133,9 +250,19 @@ var appConfigSetMap = map[string]cli.StringOpt{
 	"log.debug": logDebugOpt,
 
 	"relayer.endpoint": relayerEndpointOpt,
+	"chronos.endpoint": chronosEndpointOpt,
 
 	"accounts.keystore": accountsKeystoreOpt,
 	"accounts.default":  accountsDefaultOpt,
+	"accounts.agent":    accountsAgentOpt,
+	"accounts.signer":   accountsSignerOpt,
+
+	"monitor.interval":                   monitorIntervalOpt,
+	"monitor.margin_ratio_alert":         monitorMarginRatioAlertOpt,
+	"monitor.liquidation_distance_alert": monitorLiquidationDistanceAlertOpt,
+	"monitor.auto_reduce":                monitorAutoReduceOpt,
+	"monitor.auto_reduce_ratio":          monitorAutoReduceRatioOpt,
+	"monitor.auto_reduce_percent":        monitorAutoReducePercentOpt,
 
 	"networks.allow_gas_oracles": networksAllowGasOraclesOpt,
 	"networks.default":           networksDefaultOpt,
@@ -256,7 +383,14 @@ func writeInitialConfig(configPath string, configMapping map[string]*string) (*t
 	return tree, nil
 }
 
+// configMux guards the config tree, which is also accessed by background
+// goroutines, such as the keystore watcher and the risk monitor.
+var configMux = new(sync.RWMutex)
+
 func saveConfig(configPath string, config *toml.Tree) error {
+	configMux.Lock()
+	defer configMux.Unlock()
+
 	cfgFile, err := os.OpenFile(configPath, os.O_EXCL|os.O_TRUNC|os.O_RDWR, 0600)
 	if err != nil {
 		err = errors.Wrap(err, "unable to open config file")
@@ -291,3 +425,13 @@ func toBool(s string) bool {
 		return false
 	}
 }
+
+func stringInSlice(s string, list []string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
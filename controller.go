@@ -1,12 +1,16 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"math/big"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,6 +20,8 @@ import (
 	zeroex "github.com/InjectiveLabs/zeroex-go"
 	"github.com/c-bata/go-prompt"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/fatih/color"
 	homedir "github.com/mitchellh/go-homedir"
 	toml "github.com/pelletier/go-toml"
@@ -28,8 +34,12 @@ import (
 
 	"github.com/InjectiveLabs/dexterm/clients"
 	"github.com/InjectiveLabs/dexterm/ethereum/ethcore"
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/agent"
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/clef"
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/hdwallet"
 	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
 	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/manager"
+	sdaAPI "github.com/InjectiveLabs/dexterm/gen/derivatives_api"
 	sraAPI "github.com/InjectiveLabs/dexterm/gen/relayer_api"
 	restAPI "github.com/InjectiveLabs/dexterm/gen/rest_api"
 )
@@ -49,6 +59,7 @@ type AppController struct {
 	sraClient         *clients.SRAClient
 	sdaClient         *clients.SDAClient
 	coordinatorClient *clients.CoordinatorClient
+	chronosClient     *clients.ChronosClient
 
 	ethGasPrice         *big.Int
 	ethCore             *ethcore.EthClient
@@ -56,6 +67,13 @@ type AppController struct {
 
 	keystorePath string
 	keystore     keystore.EthKeyStore
+	session      *SigningSession
+	remoteSigner bool
+
+	// signals that the keystore watcher found new accounts
+	walletsAddedC chan struct{}
+
+	riskMonitor *RiskMonitor
 }
 
 func NewAppController(configPath string) (*AppController, error) {
@@ -65,9 +83,11 @@ func NewAppController(configPath string) (*AppController, error) {
 	}
 
 	ctl := &AppController{
-		cfg:        cfg,
-		configPath: configPath,
+		cfg:           cfg,
+		configPath:    configPath,
+		walletsAddedC: make(chan struct{}, 1),
 	}
+	ctl.riskMonitor = NewRiskMonitor(ctl)
 
 	if debugClient, err := clients.NewDebugClient(&clients.DebugClientConfig{
 		Endpoint: ctl.mustConfigValue("relayer.endpoint"),
@@ -104,6 +124,19 @@ func NewAppController(configPath string) (*AppController, error) {
 			ctl.sdaClient = sdaClient
 		}
 
+		chronosEndpoint, ok := ctl.getConfigValue("chronos.endpoint")
+		if !ok || len(chronosEndpoint) == 0 {
+			chronosEndpoint = ctl.mustConfigValue("relayer.endpoint")
+		}
+
+		if chronosClient, err := clients.NewChronosClient(&clients.ChronosClientConfig{
+			Endpoint: chronosEndpoint,
+		}); err != nil {
+			logrus.WithError(err).Warningln("no Chronos HTTP connection")
+		} else {
+			ctl.chronosClient = chronosClient
+		}
+
 		coordinatorEndpoint := ctl.mustConfigValue("relayer.endpoint")
 
 		if coordinatorClient, err := clients.NewCoordinatorClient(&clients.CoordinatorClientConfig{
@@ -130,8 +163,7 @@ func NewAppController(configPath string) (*AppController, error) {
 		logrus.WithField("address", ctl.feeRecipientAddress.Hex()).Println("SRA endpoint provided by staker")
 	}
 
-	keystorePath := ctl.mustConfigValue("accounts.keystore")
-	keystorePath, _ = homedir.Expand(keystorePath)
+	keystorePath := ctl.keystorePaths()[0]
 
 	if err := os.MkdirAll(keystorePath, 0700); err != nil {
 		return nil, err
@@ -139,21 +171,58 @@ func NewAppController(configPath string) (*AppController, error) {
 
 	ctl.keystorePath = keystorePath
 
-	kb, err := keystore.New(keystorePath)
-	if err != nil {
+	if signerSpec, ok := ctl.getConfigValue("accounts.signer"); ok && len(signerSpec) > 0 {
+		signerEndpoint, err := clef.ParseSignerSpec(signerSpec)
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.Contains(signerEndpoint, "://") {
+			// IPC socket path
+			signerEndpoint, _ = homedir.Expand(signerEndpoint)
+		}
+
+		if ctl.keystore, err = clef.NewKeyStore(signerEndpoint); err != nil {
+			err = errors.Wrap(err, "failed to connect to external signer")
+			return nil, err
+		}
+
+		ctl.remoteSigner = true
+
+		logrus.WithField("signer", signerEndpoint).Infoln("Signing with external signer")
+	} else if agentSocket, ok := ctl.getConfigValue("accounts.agent"); ok && len(agentSocket) > 0 {
+		agentSocket, _ = homedir.Expand(agentSocket)
+		ctl.keystore = agent.NewKeyStore(agent.NewClient(agentSocket))
+		ctl.remoteSigner = true
+
+		logrus.WithField("socket", agentSocket).Infoln("Signing with dexterm agent")
+	} else if kb, err := ctl.openKeystore(); err != nil {
 		return nil, err
 	} else {
 		ctl.keystore = kb
+		ctl.watchKeystore(kb)
 	}
 
-	if ctl.takeFirstAccountAsDefault() {
-		saveConfig(ctl.configPath, ctl.cfg)
-	} else if _, ok := ctl.getConfigValue("accounts.default"); !ok {
-		ctl.generateDefaultAccount()
+	ctl.keystore = newWatchOnlyGuard(ctl.keystore, ctl.isWatchOnly)
+	ctl.session = NewSigningSession(ctl.keystore)
+
+	if ctl.selectDefaultNetwork() {
 		saveConfig(ctl.configPath, ctl.cfg)
 	}
 
-	if ctl.selectDefaultNetwork() {
+	if ctl.takeFirstAccountAsDefault() {
+		saveConfig(ctl.configPath, ctl.cfg)
+	} else if _, ok := ctl.getConfigValue("accounts.default"); !ok && !ctl.remoteSigner {
+		if *insecureDevAccount {
+			err = ctl.generateDefaultAccount()
+		} else {
+			err = ctl.runAccountWizard()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
 		saveConfig(ctl.configPath, ctl.cfg)
 	}
 
@@ -189,6 +258,7 @@ type TradeDerivativeLimitOrderArgs struct {
 	Market       string
 	Quantity     string
 	Price        string
+	Leverage     string
 	SignPassword string
 }
 
@@ -216,12 +286,14 @@ func (ctl *AppController) ActionTradeDerivativesLimitLong(args interface{}) {
 
 	var makerAssetData []byte
 	var takerAssetData []byte
+	var marketID common.Hash
 
 	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
 	for _, market := range markets {
 		if market.Ticker != makeDerivativeOrderArgs.Market {
 			continue
 		}
+		marketID = common.HexToHash(market.MarketID)
 		makerAssetData = common.FromHex(market.MarketID + "00000000")
 		takerAssetData = common.FromHex("0x000000000000000000000000000000000000000000000000000000000000000000000000")
 	}
@@ -253,6 +325,12 @@ func (ctl *AppController) ActionTradeDerivativesLimitLong(args interface{}) {
 	}
 	makerAssetAmount = dec2big(price)
 
+	margin, err := ctl.derivativesOrderMargin(ctx, marketID, price, takerAssetAmount, makeDerivativeOrderArgs.Leverage, true)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to calculate order margin")
+		return
+	}
+
 	callArgs := &ethcore.CallArgs{
 		Context:  ctx,
 		From:     defaultAccount,
@@ -267,6 +345,7 @@ func (ctl *AppController) ActionTradeDerivativesLimitLong(args interface{}) {
 		makerAssetAmount,
 		takerAssetAmount,
 		true,
+		margin,
 	)
 	if err != nil {
 		logrus.WithError(err).Errorln("unable to sign order")
@@ -297,6 +376,7 @@ func (ctl *AppController) ActionTradeDerivativesLimitShort(args interface{}) {
 
 	var makerAssetData []byte
 	var takerAssetData []byte
+	var marketID common.Hash
 
 	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
 	for _, market := range markets {
@@ -304,6 +384,7 @@ func (ctl *AppController) ActionTradeDerivativesLimitShort(args interface{}) {
 			continue
 		}
 		// TODO: need to call getAccounts to get list of accountIDs to allow trader to select account to trade from earlier
+		marketID = common.HexToHash(market.MarketID)
 		makerAssetData = common.FromHex("0x000000000000000000000000000000000000000000000000000000000000000000000000")
 		takerAssetData = common.FromHex(market.MarketID + "00000000")
 	}
@@ -334,6 +415,12 @@ func (ctl *AppController) ActionTradeDerivativesLimitShort(args interface{}) {
 	}
 	makerAssetAmount = dec2big(price)
 
+	margin, err := ctl.derivativesOrderMargin(ctx, marketID, price, takerAssetAmount, makeDerivativeOrderArgs.Leverage, false)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to calculate order margin")
+		return
+	}
+
 	callArgs := &ethcore.CallArgs{
 		Context:  ctx,
 		From:     defaultAccount,
@@ -348,6 +435,7 @@ func (ctl *AppController) ActionTradeDerivativesLimitShort(args interface{}) {
 		makerAssetAmount,
 		takerAssetAmount,
 		false,
+		margin,
 	)
 	if err != nil {
 		logrus.WithError(err).Errorln("unable to sign order")
@@ -363,6 +451,91 @@ func (ctl *AppController) ActionTradeDerivativesLimitShort(args interface{}) {
 	fmt.Println(orderHash)
 }
 
+// derivativesOrderMargin calculates margin of a derivatives order for the specified leverage,
+// validates it against the minimum margin of the market and prints the order summary.
+func (ctl *AppController) derivativesOrderMargin(
+	ctx context.Context,
+	marketID common.Hash,
+	price decimal.Decimal,
+	quantity *big.Int,
+	leverageStr string,
+	isLong bool,
+) (*big.Int, error) {
+	leverage, err := parseLeverage(leverageStr)
+	if err != nil {
+		return nil, err
+	}
+
+	quantityDec := decimal.NewFromBigInt(quantity, 0)
+
+	minMarginAmount, err := ctl.ethCore.CalcMinMargin(ctx, marketID, quantity, dec2big(price))
+	if err != nil {
+		err = errors.Wrap(err, "failed to get minimum margin of the market")
+		return nil, err
+	}
+
+	minMargin := decimal.NewFromBigInt(minMarginAmount, -18)
+	margin, err := leverageMargin(price.Mul(quantityDec), leverage, minMargin)
+	if err != nil {
+		return nil, err
+	}
+
+	liquidationPrice := calcLiquidationPrice(isLong, price, quantityDec, margin, minMargin)
+
+	fmt.Printf("Margin: %s (%sx leverage, min margin %s)\n", margin.StringFixed(9), leverage.String(), minMargin.StringFixed(9))
+	fmt.Printf("Liquidation price: %s\n", liquidationPrice.StringFixed(9))
+
+	return dec2big(margin), nil
+}
+
+// parseLeverage parses the leverage of an order, no leverage by default.
+func parseLeverage(leverageStr string) (decimal.Decimal, error) {
+	if isEmpty(leverageStr) {
+		return decimal.NewFromInt(1), nil
+	}
+
+	leverage, err := decimal.NewFromString(strings.TrimSpace(leverageStr))
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse leverage")
+		return decimal.Zero, err
+	} else if leverage.LessThan(decimal.NewFromInt(1)) {
+		err = errors.New("leverage is too small, must be at least 1")
+		return decimal.Zero, err
+	}
+
+	return leverage, nil
+}
+
+// leverageMargin calculates the margin for the order notional and leverage,
+// the margin must not be less than the minimum margin of the market.
+func leverageMargin(notional, leverage, minMargin decimal.Decimal) (decimal.Decimal, error) {
+	margin := notional.Div(leverage)
+	if margin.LessThan(minMargin) {
+		maxLeverage := notional.DivRound(minMargin, 2)
+		err := errors.Errorf("leverage %sx exceeds the market limit of %sx", leverage.String(), maxLeverage.String())
+		return decimal.Zero, err
+	}
+
+	return margin, nil
+}
+
+// calcLiquidationPrice estimates the contract price at which a position will be liquidated,
+// i.e. when its margin with unrealized PnL falls below the minimum margin.
+func calcLiquidationPrice(isLong bool, price, quantity, margin, minMargin decimal.Decimal) decimal.Decimal {
+	buffer := margin.Sub(minMargin).Div(quantity)
+
+	if isLong {
+		liquidationPrice := price.Sub(buffer)
+		if liquidationPrice.IsNegative() {
+			return decimal.Zero
+		}
+
+		return liquidationPrice
+	}
+
+	return price.Add(buffer)
+}
+
 func (ctl *AppController) ActionTradeLimitBuy(args interface{}) {
 	makeBuyOrderArgs := args.(*TradeLimitBuyOrderArgs)
 
@@ -1154,6 +1327,16 @@ type TradeDerivativeOrderbookArgs struct {
 	Market string
 }
 
+var derivativeOrderStatus = map[uint8]string{
+	0: "INVALID",
+	1: "INVALID_MAKER_ASSET_AMOUNT",
+	2: "INVALID_TAKER_ASSET_AMOUNT",
+	3: "FILLABLE",
+	4: "EXPIRED",
+	5: "FULLY_FILLED",
+	6: "CANCELLED",
+}
+
 func so2wo(o *sraAPI.Order) (wrappers.Order, []byte) {
 	makerAssetAmount, _ := big.NewInt(0).SetString(o.MakerAssetAmount, 10)
 	quantity, _ := big.NewInt(0).SetString(o.TakerAssetAmount, 10)
@@ -1180,6 +1363,11 @@ func so2wo(o *sraAPI.Order) (wrappers.Order, []byte) {
 	return wrappedOrder, common.FromHex(o.Signature)
 }
 
+// do2wo converts derivative order from SDA API into the Futures contract order and its signature.
+func do2wo(o *sdaAPI.DerivativeOrder) (wrappers.Order, []byte) {
+	return so2wo((*sraAPI.Order)(o))
+}
+
 func (ctl *AppController) ActionTradeDerivativesOrderbook(args interface{}) {
 	derivativeOrderbookArgs := args.(*TradeDerivativeOrderbookArgs)
 
@@ -1188,132 +1376,112 @@ func (ctl *AppController) ActionTradeDerivativesOrderbook(args interface{}) {
 	defer cancelFn()
 
 	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
-	markets, err := ctl.restClient.DerivativeMarkets(ctx)
-	var assetDataString string
-	for _, market := range markets {
-		if market.Ticker == derivativeOrderbookArgs.Market {
-			assetDataString = market.MarketID + "00000000"
-		}
-	}
+	ownerMark := ctl.ownerMark(defaultAccount)
 
-	bids, asks, err := ctl.sraClient.DerivativeOrders(ctx, assetDataString)
+	bids, asks, err := ctl.sdaClient.Orderbook(ctx, derivativeOrderbookArgs.Market)
 	if err != nil {
 		logrus.WithField("market", derivativeOrderbookArgs.Market).
 			WithError(err).Errorln("unable to get orderbook for market")
 		return
 	}
 
-	bidOrders := make([]wrappers.Order, len(bids))
-	bidSignatures := make([][]byte, len(bids))
+	if err := ctl.annotateDerivativeOrders(ctx, bids); err != nil {
+		logrus.WithError(err).Errorln("unable to get states of bid orders")
+		return
+	} else if err := ctl.annotateDerivativeOrders(ctx, asks); err != nil {
+		logrus.WithError(err).Errorln("unable to get states of ask orders")
+		return
+	}
 
-	for idx, bid := range bids {
-		bidOrders[idx], bidSignatures[idx] = so2wo(bid.Order)
+	makers := make([]common.Address, 0, len(bids)+len(asks))
+	for _, record := range append(bids, asks...) {
+		makers = append(makers, common.HexToAddress(record.DerivativeOrder.MakerAddress))
 	}
 
-	bidStates, err := ctl.ethCore.GetOrderRelevantStates(ctx, bidOrders, bidSignatures)
+	transferable, err := ctl.ethCore.GetTransferableAssetAmounts(ctx, makers)
 	if err != nil {
-		logrus.Error(err)
+		logrus.WithError(err).Errorln("unable to get transferable amounts of makers")
 		return
 	}
-	orderStatus := map[uint8]string{
-		0: "INVALID",
-		1: "INVALID_MAKER_ASSET_AMOUNT",
-		2: "INVALID_TAKER_ASSET_AMOUNT",
-		3: "FILLABLE",
-		4: "EXPIRED",
-		5: "FULLY_FILLED",
-		6: "CANCELLED",
-	}
-	logrus.Info("========= BIDS ======")
-	for idx, fillable := range bidStates.FillableTakerAssetAmounts {
-		bids[idx].MetaData["remainingTakerAssetAmount"] = fillable.String()
-		if bidStates.IsValidSignature[idx] == false {
-			bids[idx].MetaData["remainingTakerAssetAmount"] = "0"
-		}
-		//transferrableAmount, _ := ctl.ethCore.GetTransferableAssetAmount(ctx, common.HexToAddress(bids[idx].Order.MakerAddress))
-		logrus.Info("Order Status: ", orderStatus[bidStates.OrdersInfo[idx].OrderStatus])
-		logrus.Info(bidStates.OrdersInfo[idx].OrderTakerAssetFilledAmount.String(), "/", bids[idx].Order.TakerAssetAmount, " contracts filled")
-		logrus.Info("Fillable: ", bids[idx].MetaData["remainingTakerAssetAmount"])
+
+	// margin available to makers is shared by all their orders on both sides
+	available := make(map[common.Address]decimal.Decimal, len(transferable))
+	for maker, amount := range transferable {
+		available[maker] = decimal.NewFromBigInt(amount, 0)
 	}
 
-	askOrders := make([]wrappers.Order, len(asks))
-	askSignatures := make([][]byte, len(asks))
+	bidLevels := aggregateDerivativeLevels(bids, defaultAccount, available)
+	askLevels := aggregateDerivativeLevels(asks, defaultAccount, available)
 
-	for idx, ask := range asks {
-		askOrders[idx], askSignatures[idx] = so2wo(ask.Order)
-	}
-	logrus.Info("========= ASKS ======")
+	// best bid is the highest price, best ask is the lowest one
+	sort.Slice(bidLevels, func(i, j int) bool {
+		return bidLevels[i].Price.GreaterThan(bidLevels[j].Price)
+	})
+	sort.Slice(askLevels, func(i, j int) bool {
+		return askLevels[i].Price.LessThan(askLevels[j].Price)
+	})
 
-	askStates, err := ctl.ethCore.GetOrderRelevantStates(ctx, askOrders, askSignatures)
-	if err != nil {
-		logrus.Error(err)
-		return
+	cumulative := decimal.Zero
+	for _, level := range bidLevels {
+		cumulative = cumulative.Add(level.Quantity)
+		level.Total = cumulative
 	}
 
-	for idx, fillable := range askStates.FillableTakerAssetAmounts {
-		asks[idx].MetaData["remainingTakerAssetAmount"] = fillable.String()
-		if askStates.IsValidSignature[idx] == false {
-			asks[idx].MetaData["remainingTakerAssetAmount"] = "0"
-		}
-		transferrableAmount, _ := ctl.ethCore.GetTransferableAssetAmount(ctx, common.HexToAddress(asks[idx].Order.MakerAddress))
-		logrus.Info("Order Status: ", orderStatus[askStates.OrdersInfo[idx].OrderStatus], "\tTransferrable: ", transferrableAmount.String())
-		logrus.Info(askStates.OrdersInfo[idx].OrderTakerAssetFilledAmount.String(), "/", asks[idx].Order.TakerAssetAmount, " contracts filled")
-		logrus.Info("Fillable: ", asks[idx].MetaData["remainingTakerAssetAmount"])
+	cumulative = decimal.Zero
+	for _, level := range askLevels {
+		cumulative = cumulative.Add(level.Quantity)
+		level.Total = cumulative
 	}
-	//logrus.Info(bidStates.FillableTakerAssetAmounts)
-	//logrus.Info(askStates.FillableTakerAssetAmounts)
 
 	table := termtables.CreateTable()
 	table.UTF8Box()
-	table.AddTitle("ORDERBOOK")
+	table.AddTitle(fmt.Sprintf("ORDERBOOK %s", derivativeOrderbookArgs.Market))
 	table.AddHeaders(
-		fmt.Sprintf("Price"),
-		fmt.Sprintf("Contracts"),
+		"Price",
+		"Contracts",
+		"Total",
 		"Notes",
 	)
 
-	if len(asks) == 0 {
-		table.AddRow(color.RedString("No asks."), "", "")
+	if len(askLevels) == 0 {
+		table.AddRow(color.RedString("No asks."), "", "", "")
 	} else {
-		for _, ask := range asks {
-			var notes string
-			if isMakerOf(ask.Order, defaultAccount) {
-				notes = "⭑ owner"
-			}
-
-			price := decimal.RequireFromString(ask.Order.MakerAssetAmount).Truncate(9).Shift(-18).String()
-			quantity := decimal.RequireFromString(ask.MetaData["remainingTakerAssetAmount"])
-			if quantity.IsZero() {
-				continue
-			}
+		// asks are rendered from the top, so the best ask is right above the spread
+		for idx := len(askLevels) - 1; idx >= 0; idx-- {
+			level := askLevels[idx]
 			table.AddRow(
-				color.RedString("%s", price),
-				color.RedString("%s", quantity),
-				notes,
+				color.RedString("%s", level.Price.StringFixed(9)),
+				color.RedString("%s", level.Quantity.String()),
+				color.RedString("%s", level.Total.String()),
+				level.notes(ownerMark),
 			)
 		}
 	}
 
 	table.AddSeparator()
 
-	if len(bids) == 0 {
-		table.AddRow(color.GreenString("No bids."), "", "")
-	} else {
-		for _, bid := range bids {
-			var notes string
-			if isMakerOf(bid.Order, defaultAccount) {
-				notes = "⭑ owner"
-			}
+	if len(bidLevels) > 0 && len(askLevels) > 0 {
+		bestBid := bidLevels[0].Price
+		bestAsk := askLevels[0].Price
 
-			price := decimal.RequireFromString(bid.Order.MakerAssetAmount).Truncate(9).Shift(-18).String()
-			quantity := decimal.RequireFromString(bid.MetaData["remainingTakerAssetAmount"])
-			if quantity.IsZero() {
-				continue
-			}
+		table.AddRow(
+			fmt.Sprintf("Mid %s", bestAsk.Add(bestBid).Div(decimal.NewFromInt(2)).StringFixed(9)),
+			fmt.Sprintf("Spread %s", bestAsk.Sub(bestBid).StringFixed(9)),
+			"",
+			"",
+		)
+		table.AddSeparator()
+	}
+
+	if len(bidLevels) == 0 {
+		table.AddRow(color.GreenString("No bids."), "", "", "")
+	} else {
+		for _, level := range bidLevels {
 			table.AddRow(
-				color.GreenString("%s", price),
-				color.GreenString("%s", quantity),
-				notes,
+				color.GreenString("%s", level.Price.StringFixed(9)),
+				color.GreenString("%s", level.Quantity.String()),
+				color.GreenString("%s", level.Total.String()),
+				level.notes(ownerMark),
 			)
 		}
 	}
@@ -1321,545 +1489,2621 @@ func (ctl *AppController) ActionTradeDerivativesOrderbook(args interface{}) {
 	fmt.Println(table.Render())
 }
 
-type TradeOrderbookArgs struct {
-	Market string
+type derivativeOrderbookLevel struct {
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+	Total    decimal.Decimal
+	Orders   int
+	Own      bool
 }
 
-func (ctl *AppController) ActionTradeOrderbook(args interface{}) {
-	orderbookArgs := args.(*TradeOrderbookArgs)
+func (l *derivativeOrderbookLevel) notes(ownerMark string) string {
+	var notes string
+	if l.Own {
+		notes = "⭑ " + ownerMark + " "
+	}
 
-	ctx := context.Background()
-	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
-	defer cancelFn()
+	if l.Orders > 1 {
+		notes += fmt.Sprintf("%d orders", l.Orders)
+	}
 
-	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	return notes
+}
 
-	bids, asks, err := ctl.sraClient.Orderbook(ctx, orderbookArgs.Market)
-	if err != nil {
-		logrus.WithField("tradePair", orderbookArgs.Market).
-			WithError(err).Errorln("unable to get orderbook for trade pair")
-		return
+// aggregateDerivativeLevels groups fillable orders by price. Orders whose makers cannot
+// provide margin for the remaining quantity are not included. Margin of included orders
+// is deducted from available amounts of makers, so a maker is not counted twice over.
+// Records with malformed amounts are skipped.
+func aggregateDerivativeLevels(
+	records []*sdaAPI.DerivativeOrderRecord,
+	owner common.Address,
+	available map[common.Address]decimal.Decimal,
+) []*derivativeOrderbookLevel {
+	levelsByPrice := make(map[string]*derivativeOrderbookLevel)
+	levels := make([]*derivativeOrderbookLevel, 0, len(records))
+
+	for _, record := range records {
+		order := record.DerivativeOrder
+
+		price, fillable, marginRequired, err := parseDerivativeRecord(record)
+		if err != nil {
+			logrus.WithError(err).Warningf("skipping order %s", derivativeOrderHash(order).Hex())
+			continue
+		} else if fillable.IsZero() {
+			continue
+		}
+
+		maker := common.HexToAddress(order.MakerAddress)
+		if amount, ok := available[maker]; ok {
+			if amount.LessThan(marginRequired) {
+				continue
+			}
+
+			available[maker] = amount.Sub(marginRequired)
+		}
+
+		level, ok := levelsByPrice[price.String()]
+		if !ok {
+			level = &derivativeOrderbookLevel{
+				Price: price,
+			}
+
+			levelsByPrice[price.String()] = level
+			levels = append(levels, level)
+		}
+
+		level.Quantity = level.Quantity.Add(fillable)
+		level.Orders++
+		if maker == owner {
+			level.Own = true
+		}
 	}
 
-	bidOrders := make([]wrappers.Order, len(bids))
-	bidSignatures := make([][]byte, len(bids))
+	return levels
+}
 
-	for idx, bid := range bids {
-		bidOrders[idx], bidSignatures[idx] = so2wo(bid.Order)
+// parseDerivativeRecord parses the price, remaining quantity and the margin locked by maker
+// for the remaining quantity, as reported by the relayer.
+func parseDerivativeRecord(record *sdaAPI.DerivativeOrderRecord) (price, fillable, margin decimal.Decimal, err error) {
+	order := record.DerivativeOrder
+
+	if fillable, err = decimal.NewFromString(record.MetaData["remainingTakerAssetAmount"]); err != nil {
+		err = errors.Wrap(err, "failed to parse remaining quantity")
+		return
 	}
 
-	bidStates, err := ctl.ethCore.GetZeroExOrderRelevantStates(ctx, bidOrders, bidSignatures)
+	quantity, err := decimal.NewFromString(order.TakerAssetAmount)
 	if err != nil {
-		logrus.Error(err)
+		err = errors.Wrap(err, "failed to parse quantity")
+		return
+	} else if !quantity.IsPositive() {
+		err = errors.New("order quantity is zero")
 		return
-	}
-	orderStatus := map[uint8]string{
-		0: "INVALID",
-		1: "INVALID_MAKER_ASSET_AMOUNT",
-		2: "INVALID_TAKER_ASSET_AMOUNT",
-		3: "FILLABLE",
-		4: "EXPIRED",
-		5: "FULLY_FILLED",
-		6: "CANCELLED",
 	}
 
-	for idx, fillable := range bidStates.FillableTakerAssetAmounts {
-		// TODO: (@Maxim) see why fillable is not correct
-		bids[idx].MetaData["filled"] = bidStates.OrdersInfo[idx].OrderTakerAssetFilledAmount.String()
-		bids[idx].MetaData["fillableTakerAssetAmount"] = bids[idx].MetaData["remainingTakerAssetAmount"]
-		bids[idx].MetaData["fillable"] = decimal.RequireFromString(fillable.String()).Shift(-18).StringFixed(5)
-		if bidStates.IsValidSignature[idx] == false {
-			bids[idx].MetaData["fillable"] = decimal.RequireFromString("0").Shift(-18).StringFixed(5)
-		}
-		price, _ := calcOrderPrice(bids[idx].Order, bids[idx].MetaData["remainingTakerAssetAmount"], true)
-		bids[idx].MetaData["price"] = price.StringFixed(9)
-		if orderStatus[bidStates.OrdersInfo[idx].OrderStatus] == "FULLY_FILLED" {
-			bids[idx].MetaData["notes"] = " " + decimal.RequireFromString(bids[idx].Order.TakerAssetAmount).Shift(-18).StringFixed(5) + " " + orderStatus[bidStates.OrdersInfo[idx].OrderStatus]
-		} else {
-			bids[idx].MetaData["notes"] = " " + bids[idx].MetaData["fillable"] + "/" + decimal.RequireFromString(bids[idx].Order.TakerAssetAmount).Shift(-18).StringFixed(5) + " remaining " + orderStatus[bidStates.OrdersInfo[idx].OrderStatus]
-		}
+	makerFee, err := decimal.NewFromString(order.MakerFee)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse margin")
+		return
+	}
 
+	if price, err = decimal.NewFromString(order.MakerAssetAmount); err != nil {
+		err = errors.Wrap(err, "failed to parse price")
+		return
 	}
 
-	askOrders := make([]wrappers.Order, len(asks))
-	askSignatures := make([][]byte, len(asks))
+	// margin locked by maker is proportional to the remaining quantity
+	margin = makerFee.Mul(fillable).Div(quantity)
+	price = price.Shift(-18)
 
-	for idx, ask := range asks {
-		askOrders[idx], askSignatures[idx] = so2wo(ask.Order)
-	}
+	return price, fillable, margin, nil
+}
 
-	askStates, err := ctl.ethCore.GetZeroExOrderRelevantStates(ctx, askOrders, askSignatures)
+// marketSummaryResolution is the period of market summaries shown in markets list.
+const marketSummaryResolution = "24h"
+
+func (ctl *AppController) ActionDerivativesMarkets() {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	markets, err := ctl.restClient.DerivativeMarkets(ctx)
 	if err != nil {
-		logrus.Error(err)
+		logrus.WithError(err).Errorln("unable to fetch derivatives markets")
 		return
 	}
 
-	for idx, fillable := range askStates.FillableTakerAssetAmounts {
-		asks[idx].MetaData["fillableTakerAssetAmount"] = asks[idx].MetaData["remainingTakerAssetAmount"]
-		// TODO: (@Maxim) see why fillable is not correct
-		asks[idx].MetaData["fillable"] = decimal.RequireFromString(fillable.String()).Shift(-18).StringFixed(5)
-		if askStates.IsValidSignature[idx] == false {
-			asks[idx].MetaData["fillable"] = decimal.RequireFromString("0").Shift(-18).StringFixed(5)
+	tokenSymbols := make(map[common.Address]string)
+	if tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx); err == nil {
+		tickers := make(map[string]bool, len(markets))
+		for _, market := range markets {
+			tickers[market.Ticker] = true
 		}
-		price, _ := calcOrderPrice(asks[idx].Order, asks[idx].MetaData["remainingTakerAssetAmount"], false)
-		asks[idx].MetaData["price"] = price.StringFixed(9)
-		if orderStatus[askStates.OrdersInfo[idx].OrderStatus] == "FULLY_FILLED" {
-			asks[idx].MetaData["notes"] = " " + decimal.RequireFromString(asks[idx].Order.TakerAssetAmount).Shift(-18).StringFixed(5) + " " + orderStatus[askStates.OrdersInfo[idx].OrderStatus]
-		} else {
-			asks[idx].MetaData["notes"] = " " + asks[idx].MetaData["fillable"] + "/" + decimal.RequireFromString(asks[idx].Order.TakerAssetAmount).Shift(-18).StringFixed(5) + " remaining " + orderStatus[askStates.OrdersInfo[idx].OrderStatus]
+
+		for idx, name := range tokenNames {
+			if !tickers[name] {
+				tokenSymbols[assets[idx]] = name
+			}
 		}
 	}
 
-	pair := strings.Split(orderbookArgs.Market, "/")
-	baseAsset := pair[0]
-	quoteAsset := pair[1]
-
 	table := termtables.CreateTable()
 	table.UTF8Box()
-	table.AddTitle("ORDERBOOK")
+	table.AddTitle("DERIVATIVES MARKETS")
 	table.AddHeaders(
-		fmt.Sprintf("Price (%s)", quoteAsset),
-		fmt.Sprintf("Amount (%s)", baseAsset),
-		"Notes",
+		"Ticker",
+		"Base",
+		"Oracle",
+		"Oracle Price",
+		"Last Price",
+		fmt.Sprintf("Change %s", marketSummaryResolution),
+		fmt.Sprintf("High / Low %s", marketSummaryResolution),
+		fmt.Sprintf("Volume %s", marketSummaryResolution),
+		"Status",
 	)
-	zero := decimal.RequireFromString("0").Shift(-18).StringFixed(5)
-	sort.Slice(asks, func(i, j int) bool {
-		if asks[i].MetaData["fillable"] == zero {
-			return true
-		} else if asks[j].MetaData["fillable"] == zero {
-			return false
-		}
-		return decimal.RequireFromString(asks[i].MetaData["price"]).GreaterThan(decimal.RequireFromString(asks[j].MetaData["price"]))
-	})
 
-	sort.Slice(bids, func(i, j int) bool {
-		if bids[i].MetaData["fillable"] == zero {
-			return false
-		} else if bids[j].MetaData["fillable"] == zero {
-			return true
+	for _, market := range markets {
+		baseCurrency := common.HexToAddress(market.BaseCurrency)
+		baseSymbol, ok := tokenSymbols[baseCurrency]
+		if !ok {
+			baseSymbol = baseCurrency.Hex()
 		}
-		return decimal.RequireFromString(bids[i].MetaData["price"]).GreaterThan(decimal.RequireFromString(bids[j].MetaData["price"]))
-	})
-	if len(asks) == 0 {
-		table.AddRow(color.RedString("No asks."), "", "")
-	} else {
-		for _, ask := range asks {
-			var notes string
-			if isMakerOf(ask.Order, defaultAccount) {
-				notes = "⭑ owner"
+
+		oraclePrice := "N/A"
+		if price, err := ctl.getOraclePrice(ctx, market); err != nil {
+			logrus.WithField("market", market.Ticker).WithError(err).Warningln("unable to get oracle price")
+		} else {
+			oraclePrice = decimal.NewFromBigInt(price, -18).StringFixed(9)
+		}
+
+		lastPrice, change, highLow, volume := "N/A", "N/A", "N/A", "N/A"
+		if ctl.chronosClient != nil {
+			summary, err := ctl.chronosClient.FuturesMarketSummary(ctx, market.MarketID, marketSummaryResolution)
+			if err != nil {
+				logrus.WithField("market", market.Ticker).WithError(err).Warningln("unable to get market summary")
+			} else {
+				lastPrice = decimal.NewFromFloat(summary.Price).StringFixed(9)
+				highLow = fmt.Sprintf("%s / %s",
+					decimal.NewFromFloat(summary.High).StringFixed(6),
+					decimal.NewFromFloat(summary.Low).StringFixed(6),
+				)
+				volume = decimal.NewFromFloat(summary.Volume).String()
+
+				change = fmt.Sprintf("%s%%", decimal.NewFromFloat(summary.Change).StringFixed(2))
+				if summary.Change < 0 {
+					change = color.RedString(change)
+				} else {
+					change = color.GreenString(change)
+				}
 			}
+		}
 
-			notes += ask.MetaData["notes"]
-			table.AddRow(
-				color.RedString("%s", ask.MetaData["price"]),
-				color.RedString("%s", ask.MetaData["fillable"]),
-				notes,
-			)
+		status := color.GreenString("enabled")
+		if !market.Enabled {
+			status = color.RedString("disabled")
 		}
+
+		table.AddRow(
+			market.Ticker,
+			baseSymbol,
+			market.Oracle,
+			oraclePrice,
+			lastPrice,
+			change,
+			highLow,
+			volume,
+			status,
+		)
 	}
 
-	table.AddSeparator()
+	fmt.Println(table.Render())
+}
 
-	if len(bids) == 0 {
-		table.AddRow(color.GreenString("No bids."), "", "")
-	} else {
-		for _, bid := range bids {
-			var notes string
-			if isMakerOf(bid.Order, defaultAccount) {
-				notes = "⭑ owner"
-			}
+// getOraclePrice reads the current price from the market oracle, falling back
+// to the index price of the market stored by the Futures contract.
+func (ctl *AppController) getOraclePrice(ctx context.Context, market *restAPI.DerivativeMarket) (*big.Int, error) {
+	if common.IsHexAddress(market.Oracle) {
+		price, err := ctl.ethCore.OraclePrice(ctx, common.HexToAddress(market.Oracle))
+		if err == nil {
+			return price, nil
+		}
 
-			notes += bid.MetaData["notes"]
+		logrus.WithField("oracle", market.Oracle).WithError(err).Debugln("oracle call failed, using index price")
+	}
 
-			price, _ := calcOrderPrice(bid.Order, bid.MetaData["remainingTakerAssetAmount"], true)
-			makerAmount := decimal.RequireFromString(bid.Order.MakerAssetAmount)
-			takerAmount := decimal.RequireFromString(bid.Order.TakerAssetAmount)
-			fillable := decimal.RequireFromString(bid.MetaData["remainingTakerAssetAmount"])
-			filled := decimal.RequireFromString(bid.MetaData["filled"])
-			fmt.Println(makerAmount.Shift(-18).StringFixed(5), takerAmount.Shift(-18).StringFixed(5), fillable.Shift(-18).StringFixed(5), filled.Shift(-18).StringFixed(5))
-			// (takerAmount - fillable) / takerAmount * makerAmount
+	return ctl.ethCore.MarketIndexPrice(ctx, common.HexToHash(market.MarketID))
+}
 
-			table.AddRow(
-				color.GreenString("%s", price.StringFixed(9)),
-				color.GreenString("%s", (takerAmount.Sub(fillable)).Mul(makerAmount).Div(takerAmount).Shift(-18).StringFixed(5)),
-				notes,
-			)
+type DerivativesMonitorArgs struct {
+	SignPassword string
+}
+
+func (ctl *AppController) ActionDerivativesMonitorStart(args interface{}) {
+	monitorArgs := args.(*DerivativesMonitorArgs)
+
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	risks, err := ctl.getPositionRisks(ctx, defaultAccount)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to check positions")
+		return
+	}
+
+	printPositionRisks(risks)
+
+	if err := ctl.riskMonitor.Start(defaultAccount, monitorArgs.SignPassword); err != nil {
+		logrus.WithError(err).Errorln("unable to start risk monitor")
+		return
+	}
+
+	if toBool(ctl.mustConfigValue("monitor.auto_reduce")) && len(monitorArgs.SignPassword) == 0 {
+		logrus.Warningln("Auto-reduce is enabled, but no passphrase provided. Positions will not be reduced.")
+	}
+
+	logrus.Infof("Risk monitor started, checking positions every %s", ctl.mustConfigValue("monitor.interval"))
+}
+
+func (ctl *AppController) ActionDerivativesMonitorStop() {
+	if !ctl.riskMonitor.IsRunning() {
+		logrus.Warningln("Risk monitor is not running.")
+		return
+	}
+
+	ctl.riskMonitor.Stop()
+	logrus.Infoln("Risk monitor stopped.")
+}
+
+// RiskAlert returns a warning about positions at risk of liquidation, if any.
+func (ctl *AppController) RiskAlert() string {
+	return ctl.riskMonitor.Alert()
+}
+
+// bestCloseOrder finds the best priced order of other makers that can be used to close a position.
+// A long position is closed against long orders, a short one against short orders.
+func (ctl *AppController) bestCloseOrder(
+	ctx context.Context,
+	marketName string,
+	isLong bool,
+	owner common.Address,
+) (*sdaAPI.DerivativeOrderRecord, error) {
+	bids, asks, err := ctl.sdaClient.Orderbook(ctx, marketName)
+	if err != nil {
+		return nil, err
+	}
+
+	records := asks
+	if isLong {
+		records = bids
+	}
+
+	if err := ctl.annotateDerivativeOrders(ctx, records); err != nil {
+		return nil, err
+	}
+
+	var best *sdaAPI.DerivativeOrderRecord
+	var bestPrice decimal.Decimal
+
+	for _, record := range records {
+		if isDerivativeMakerOf(record.DerivativeOrder, owner) {
+			continue
+		}
+
+		price, fillable, _, err := parseDerivativeRecord(record)
+		if err != nil || fillable.IsZero() {
+			continue
+		}
+
+		if best == nil ||
+			(isLong && price.GreaterThan(bestPrice)) ||
+			(!isLong && price.LessThan(bestPrice)) {
+			best = record
+			bestPrice = price
 		}
 	}
 
-	fmt.Println(table.Render())
+	if best == nil {
+		return nil, errors.New("no orders available to close position")
+	}
+
+	return best, nil
 }
 
-func isMakerOf(order *sraAPI.Order, address common.Address) bool {
-	return bytes.Compare(
-		common.HexToAddress(order.MakerAddress).Bytes(),
-		address.Bytes(),
-	) == 0
+type TradeDerivativeCloseArgs struct {
+	Market       string
+	Percent      string
+	Price        string
+	PositionID   string
+	SignPassword string
 }
 
-func (ctl *AppController) getTokenNamesAndAssets(ctx context.Context) (tokenNames []string, assets []common.Address, err error) {
-	if ctl.sraClient == nil {
-		err := errors.New("client in offline mode")
-		return nil, nil, err
+func (ctl *AppController) ActionTradeDerivativesClose(args interface{}) {
+	closeArgs := args.(*TradeDerivativeCloseArgs)
+
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	market, err := ctl.getDerivativesMarket(ctx, closeArgs.Market)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"market": closeArgs.Market,
+		}).WithError(err).Errorln("specified market not found or is not enabled")
+		return
 	}
 
-	pairs, err := ctl.restClient.TradePairs(ctx)
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	marketID := common.HexToHash(market.MarketID)
+
+	positions, err := ctl.ethCore.GetPositionsForTrader(ctx, defaultAccount, marketID)
 	if err != nil {
-		err = errors.Wrap(err, "failed to list trade pairs")
-		return tokenNames, assets, err
+		logrus.WithError(err).Errorln("unable to get positions")
+		return
+	} else if len(positions) == 0 {
+		logrus.WithField("market", closeArgs.Market).Errorln("no open position in market")
+		return
 	}
 
-	markets, err := ctl.restClient.DerivativeMarkets(ctx)
+	position, err := selectPosition(positions, closeArgs.PositionID)
 	if err != nil {
-		logrus.WithError(err).Errorln("unable to fetch trade pairs")
+		logrus.WithField("market", closeArgs.Market).WithError(err).Errorln("unable to select position to close")
 		return
 	}
 
-	tokenMap := make(map[string]common.Address, len(pairs)+len(markets))
+	positionQuantity := decimal.NewFromBigInt(position.Quantity, 0)
 
-	for _, pair := range pairs {
-		parts := strings.Split(pair.Name, "/")
-		if len(parts) != 2 {
-			continue
+	percent := decimal.NewFromInt(100)
+	if len(closeArgs.Percent) > 0 {
+		if percent, err = decimal.NewFromString(closeArgs.Percent); err != nil {
+			logrus.WithError(err).Errorln("failed to parse percent")
+			return
+		} else if !percent.IsPositive() || percent.GreaterThan(decimal.NewFromInt(100)) {
+			logrus.Errorln("percent must be greater than 0 and not more than 100")
+			return
 		}
+	}
 
-		tokenMap[parts[0]] = common.HexToAddress("0x" + pair.MakerAssetData[len(pair.MakerAssetData)-40:])
-		tokenMap[parts[1]] = common.HexToAddress("0x" + pair.TakerAssetData[len(pair.TakerAssetData)-40:])
+	quantity := positionQuantity.Mul(percent).Div(decimal.NewFromInt(100)).Ceil()
+
+	var closeOrder *sdaAPI.DerivativeOrderRecord
+	var price decimal.Decimal
+
+	isMarketClose := len(closeArgs.Price) == 0 || strings.ToLower(closeArgs.Price) == "market"
+	if isMarketClose {
+		if closeOrder, err = ctl.bestCloseOrder(ctx, market.Ticker, position.IsLong(), defaultAccount); err != nil {
+			logrus.WithError(err).Errorln("unable to close position at market")
+			return
+		}
+
+		var fillable decimal.Decimal
+		if price, fillable, _, err = parseDerivativeRecord(closeOrder); err != nil {
+			logrus.WithError(err).Errorln("unable to close position at market")
+			return
+		} else if quantity.GreaterThan(fillable) {
+			err = fmt.Errorf("best order has only %s contracts available", fillable.String())
+			logrus.WithError(err).Errorln("close a smaller part of position or specify a limit price")
+			return
+		}
+	} else if price, err = decimal.NewFromString(closeArgs.Price); err != nil {
+		logrus.WithError(err).Errorln("failed to parse price")
+		return
+	} else if !price.IsPositive() {
+		logrus.Errorln("price must be greater than 0")
+		return
 	}
 
-	for _, market := range markets {
-		tokenMap[market.Ticker] = common.HexToAddress(market.BaseCurrency)
+	entryPrice := decimal.NewFromBigInt(position.ContractPrice, -18)
+	pnl := price.Sub(entryPrice).Mul(quantity)
+	if !position.IsLong() {
+		pnl = pnl.Neg()
 	}
 
-	if ctl.ethCore != nil {
-		// always override WETH with client-side configured address
-		tokenMap["WETH"] = ctl.ethCore.ContractAddress(ethcore.EthContractWETH9)
+	side := "LONG"
+	if !position.IsLong() {
+		side = "SHORT"
 	}
 
-	tokenNames = make([]string, 0, len(tokenMap))
-	for name := range tokenMap {
-		tokenNames = append(tokenNames, name)
+	fmt.Printf("Closing %s of %s contracts in %s position at %s (entry %s)\n",
+		quantity.String(), positionQuantity.String(), side, price.StringFixed(9), entryPrice.StringFixed(9))
+	if pnl.IsNegative() {
+		fmt.Println("Realized PnL estimate:", color.RedString(pnl.StringFixed(9)))
+	} else {
+		fmt.Println("Realized PnL estimate:", color.GreenString(pnl.StringFixed(9)))
 	}
 
-	sort.Strings(tokenNames)
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: closeArgs.SignPassword,
+		GasPrice: ctl.ethGasPrice,
+	}
 
-	assets = make([]common.Address, 0, len(tokenNames))
+	closeQuantity, _ := big.NewInt(0).SetString(quantity.String(), 10)
 
-	for _, name := range tokenNames {
-		assets = append(assets, tokenMap[name])
+	if isMarketClose {
+		order, signature := do2wo(closeOrder.DerivativeOrder)
+		txHash, err := ctl.ethCore.ClosePosition(callArgs, position.ID, order, signature, closeQuantity)
+		if err != nil {
+			logrus.WithError(err).Errorln("unable to close position")
+			return
+		}
+
+		fmt.Println(ctl.formatTxLink(txHash))
+		ctl.checkTx(txHash)
+		return
+	}
+
+	// offsetting order has the opposite direction of the position
+	emptyAssetData := common.FromHex("0x000000000000000000000000000000000000000000000000000000000000000000000000")
+	makerAssetData := common.FromHex(market.MarketID + "00000000")
+	takerAssetData := emptyAssetData
+	if position.IsLong() {
+		makerAssetData, takerAssetData = takerAssetData, makerAssetData
+	}
+
+	// commit the share of position margin being closed, not the maximum leverage
+	margin := big.NewInt(0).Mul(position.Margin, closeQuantity)
+	margin.Div(margin, position.Quantity)
+
+	minMargin, err := ctl.ethCore.CalcMinMargin(ctx, marketID, closeQuantity, dec2big(price))
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to get minimum margin of the market")
+		return
+	} else if margin.Cmp(minMargin) < 0 {
+		logrus.Warningf("Position margin is below the market minimum, committing %s instead",
+			decimal.NewFromBigInt(minMargin, -18).StringFixed(9))
+		margin = minMargin
+	}
+
+	signedOrder, err := ctl.ethCore.CreateAndSignDerivativesOrder(
+		callArgs,
+		makerAssetData,
+		takerAssetData,
+		dec2big(price),
+		closeQuantity,
+		!position.IsLong(),
+		margin,
+	)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to sign order")
+		return
+	}
+
+	orderHash, err := ctl.sdaClient.PostOrder(ctx, signedOrder)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to post order")
+		return
+	}
+
+	fmt.Println(orderHash)
+}
+
+// selectPosition picks a position by its ID, which may be omitted when there is only one.
+func selectPosition(positions []*ethcore.Position, id string) (*ethcore.Position, error) {
+	id = strings.TrimSpace(id)
+	if len(id) == 0 {
+		if len(positions) == 1 {
+			return positions[0], nil
+		}
+
+		ids := make([]string, 0, len(positions))
+		for _, position := range positions {
+			ids = append(ids, position.ID.String())
+		}
+
+		err := fmt.Errorf("found %d positions, specify ID of one: %s", len(positions), strings.Join(ids, ", "))
+		return nil, err
+	}
+
+	positionID, ok := big.NewInt(0).SetString(id, 10)
+	if !ok {
+		err := fmt.Errorf("failed to parse position ID: %s", id)
+		return nil, err
+	}
+
+	for _, position := range positions {
+		if position.ID.Cmp(positionID) == 0 {
+			return position, nil
+		}
+	}
+
+	err := fmt.Errorf("position %s not found", id)
+	return nil, err
+}
+
+type TradeDerivativeFillOrderArgs struct {
+	Market       string
+	OrderHash    string
+	Quantity     string
+	Leverage     string
+	SignPassword string
+}
+
+func (ctl *AppController) ActionTradeDerivativesFillOrder(args interface{}) {
+	fillOrderArgs := args.(*TradeDerivativeFillOrderArgs)
+
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	market, err := ctl.getDerivativesMarket(ctx, fillOrderArgs.Market)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"market": fillOrderArgs.Market,
+		}).WithError(err).Errorln("specified market not found or is not enabled")
+		return
+	}
+
+	bids, asks, err := ctl.sdaClient.Orderbook(ctx, market.Ticker)
+	if err != nil {
+		logrus.WithField("market", fillOrderArgs.Market).
+			WithError(err).Errorln("unable to get orderbook for market")
+		return
+	}
+
+	var makeOrder *sdaAPI.DerivativeOrderRecord
+	var isBid bool
+
+	orderHash := common.HexToHash(fillOrderArgs.OrderHash)
+	for _, bid := range bids {
+		if derivativeOrderHash(bid.DerivativeOrder) == orderHash {
+			makeOrder = bid
+			isBid = true
+		}
+	}
+	for _, ask := range asks {
+		if derivativeOrderHash(ask.DerivativeOrder) == orderHash {
+			makeOrder = ask
+		}
+	}
+
+	if makeOrder == nil {
+		logrus.WithFields(logrus.Fields{
+			"order": fillOrderArgs.OrderHash,
+		}).Errorln("specified order not found in the market orderbook")
+		return
+	}
+
+	if err := ctl.annotateDerivativeOrders(ctx, []*sdaAPI.DerivativeOrderRecord{makeOrder}); err != nil {
+		logrus.WithError(err).Errorln("unable to get order state")
+		return
+	}
+
+	fillable, err := decimal.NewFromString(makeOrder.MetaData["remainingTakerAssetAmount"])
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to get remaining quantity of the order")
+		return
+	}
+
+	quantityDec, err := decimal.NewFromString(fillOrderArgs.Quantity)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to parse quantity")
+		return
+	} else if !quantityDec.Equal(quantityDec.Truncate(0)) {
+		logrus.Errorf("quantity must be a whole number of contracts: %s", quantityDec.String())
+		return
+	} else if quantityDec.LessThan(decimal.NewFromInt(1)) {
+		logrus.Errorln("quantity is too small, must be at least 1")
+		return
+	} else if quantityDec.GreaterThan(fillable) {
+		err = fmt.Errorf("wrong quantity: %s", quantityDec.String())
+		logrus.WithError(err).Errorf("maximum fillable quantity: %s", fillable.String())
+		return
+	}
+
+	quantity, _ := big.NewInt(0).SetString(quantityDec.String(), 10)
+	price := decimal.RequireFromString(makeOrder.DerivativeOrder.MakerAssetAmount).Shift(-18)
+
+	// taker opens a position opposite to the maker's one
+	margin, err := ctl.derivativesOrderMargin(ctx, common.HexToHash(market.MarketID), price, quantity, fillOrderArgs.Leverage, !isBid)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to calculate order margin")
+		return
+	}
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: fillOrderArgs.SignPassword,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	order, signature := do2wo(makeOrder.DerivativeOrder)
+	txHash, err := ctl.ethCore.FillDerivativesOrder(callArgs, order, signature, quantity, margin)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to fill derivatives order")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+}
+
+type TradeDerivativeCancelOrderArgs struct {
+	Market       string
+	OrderHash    string
+	SignPassword string
+}
+
+func (ctl *AppController) ActionTradeDerivativesCancelOrder(args interface{}) {
+	cancelOrderArgs := args.(*TradeDerivativeCancelOrderArgs)
+
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	records, err := ctl.getOwnDerivativeOrders(ctx, cancelOrderArgs.Market, defaultAccount)
+	if err != nil {
+		logrus.WithField("market", cancelOrderArgs.Market).
+			WithError(err).Errorln("unable to get own orders for market")
+		return
+	}
+
+	var cancelOrder *sdaAPI.DerivativeOrderRecord
+	orderHash := common.HexToHash(cancelOrderArgs.OrderHash)
+	for _, record := range records {
+		if derivativeOrderHash(record.DerivativeOrder) == orderHash {
+			cancelOrder = record
+		}
+	}
+
+	if cancelOrder == nil {
+		logrus.WithFields(logrus.Fields{
+			"order": cancelOrderArgs.OrderHash,
+		}).Errorln("specified order not found among own orders of the market")
+		return
+	}
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: cancelOrderArgs.SignPassword,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	order, _ := do2wo(cancelOrder.DerivativeOrder)
+	txHash, err := ctl.ethCore.CancelDerivativesOrder(callArgs, order)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to cancel derivatives order")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+
+	ctl.printDerivativeOrderStates([]*sdaAPI.DerivativeOrderRecord{cancelOrder})
+}
+
+type TradeDerivativeCancelAllArgs struct {
+	Market       string
+	SignPassword string
+}
+
+func (ctl *AppController) ActionTradeDerivativesCancelAll(args interface{}) {
+	cancelAllArgs := args.(*TradeDerivativeCancelAllArgs)
+
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	records, err := ctl.getOwnDerivativeOrders(ctx, cancelAllArgs.Market, defaultAccount)
+	if err != nil {
+		logrus.WithField("market", cancelAllArgs.Market).
+			WithError(err).Errorln("unable to get own orders for market")
+		return
+	} else if len(records) == 0 {
+		logrus.Infoln("No active orders to cancel.")
+		return
+	}
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: cancelAllArgs.SignPassword,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	txHashes := make([]common.Hash, 0, len(records))
+	for _, record := range records {
+		order, _ := do2wo(record.DerivativeOrder)
+		txHash, err := ctl.ethCore.CancelDerivativesOrder(callArgs, order)
+		if err != nil {
+			logrus.WithField("order", derivativeOrderHash(record.DerivativeOrder).Hex()).
+				WithError(err).Errorln("unable to cancel derivatives order")
+			continue
+		}
+
+		fmt.Println(ctl.formatTxLink(txHash))
+		txHashes = append(txHashes, txHash)
+	}
+
+	for _, txHash := range txHashes {
+		ctl.checkTx(txHash)
+	}
+
+	ctl.printDerivativeOrderStates(records)
+}
+
+// getOwnDerivativeOrders returns still fillable orders of the market made by the given account.
+func (ctl *AppController) getOwnDerivativeOrders(
+	ctx context.Context,
+	marketName string,
+	owner common.Address,
+) ([]*sdaAPI.DerivativeOrderRecord, error) {
+	market, err := ctl.getDerivativesMarket(ctx, marketName)
+	if err != nil {
+		return nil, err
+	}
+
+	bids, asks, err := ctl.sdaClient.Orderbook(ctx, market.Ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*sdaAPI.DerivativeOrderRecord
+	for _, record := range append(bids, asks...) {
+		if isDerivativeMakerOf(record.DerivativeOrder, owner) {
+			records = append(records, record)
+		}
+	}
+
+	if err := ctl.annotateDerivativeOrders(ctx, records); err != nil {
+		return nil, err
+	}
+
+	ownOrders := records[:0]
+	for _, record := range records {
+		if record.MetaData["remainingTakerAssetAmount"] != "0" {
+			ownOrders = append(ownOrders, record)
+		}
+	}
+
+	return ownOrders, nil
+}
+
+// printDerivativeOrderStates re-fetches order states from the Futures contract and prints them.
+func (ctl *AppController) printDerivativeOrderStates(records []*sdaAPI.DerivativeOrderRecord) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelFn()
+
+	if err := ctl.annotateDerivativeOrders(ctx, records); err != nil {
+		logrus.WithError(err).Warningln("unable to check order status")
+		return
+	}
+
+	table := termtables.CreateTable()
+	table.UTF8Box()
+	table.AddTitle("ORDER STATUS")
+	table.AddHeaders("Order", "Status")
+
+	for _, record := range records {
+		status := record.MetaData["orderStatus"]
+		if status == "CANCELLED" {
+			status = color.GreenString(status)
+		} else {
+			status = color.RedString(status)
+		}
+
+		table.AddRow(derivativeOrderHash(record.DerivativeOrder).Hex(), status)
+	}
+
+	fmt.Println(table.Render())
+}
+
+type DerivativesDepositArgs struct {
+	Amount       string
+	SignPassword string
+}
+
+func (ctl *AppController) ActionDerivativesDeposit(args interface{}) {
+	depositArgs := args.(*DerivativesDepositArgs)
+
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	baseCurrency, decimals, err := ctl.getFuturesCollateral(ctx)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to get base currency of the Futures contract")
+		return
+	}
+
+	amount, err := parseTokenAmount(depositArgs.Amount, decimals)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to parse deposit amount")
+		return
+	}
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	futuresAddress := ctl.ethCore.ContractAddress(ethcore.EthContractFutures)
+
+	balance, err := ctl.ethCore.BalanceOf(ctx, defaultAccount, baseCurrency)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to get base currency balance")
+		return
+	} else if balance.Cmp(amount) < 0 {
+		logrus.Errorf("insufficient balance of base currency: %s", formatTokenAmount(balance, decimals))
+		return
+	}
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: depositArgs.SignPassword,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	allowance, err := ctl.ethCore.Allowance(ctx, defaultAccount, futuresAddress, baseCurrency)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to get allowance of the Futures contract")
+		return
+	} else if allowance.Cmp(amount) < 0 {
+		logrus.Infoln("Approving the Futures contract to spend the deposit amount")
+
+		// a deposit without the allowance would revert and burn gas
+		if err := ctl.approveAllowance(callArgs, baseCurrency, futuresAddress, allowance, amount); err != nil {
+			logrus.WithError(err).Errorln("unable to approve base currency")
+			return
+		}
+	}
+
+	freeBefore := ctl.printFreeCollateral(defaultAccount, "before", decimals)
+
+	txHash, err := ctl.ethCore.FuturesDeposit(callArgs, amount)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to deposit into the Futures contract")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+
+	if freeBefore != nil {
+		ctl.printFreeCollateral(defaultAccount, "after", decimals)
+	}
+}
+
+type DerivativesWithdrawArgs struct {
+	Amount       string
+	SignPassword string
+}
+
+func (ctl *AppController) ActionDerivativesWithdraw(args interface{}) {
+	withdrawArgs := args.(*DerivativesWithdrawArgs)
+
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	_, decimals, err := ctl.getFuturesCollateral(ctx)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to get base currency of the Futures contract")
+		return
+	}
+
+	amount, err := parseTokenAmount(withdrawArgs.Amount, decimals)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to parse withdraw amount")
+		return
+	}
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	freeBefore := ctl.printFreeCollateral(defaultAccount, "before", decimals)
+	if freeBefore != nil && freeBefore.Cmp(amount) < 0 {
+		logrus.Errorln("withdraw amount exceeds free collateral")
+		return
+	}
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: withdrawArgs.SignPassword,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	txHash, err := ctl.ethCore.FuturesWithdraw(callArgs, amount)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to withdraw from the Futures contract")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+
+	if freeBefore != nil {
+		ctl.printFreeCollateral(defaultAccount, "after", decimals)
+	}
+}
+
+// printFreeCollateral prints the amount of free collateral of the account in the Futures contract.
+// Returns nil if the amount cannot be fetched.
+func (ctl *AppController) printFreeCollateral(account common.Address, label string, decimals uint8) *big.Int {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+
+	amount, err := ctl.ethCore.GetTransferableAssetAmount(ctx, account)
+	if err != nil {
+		logrus.WithError(err).Warningln("unable to get free collateral")
+		return nil
+	}
+
+	fmt.Printf("Free collateral (%s): %s\n", label, formatTokenAmount(amount, decimals))
+	return amount
+}
+
+// getFuturesCollateral returns the collateral token of derivatives markets along with its decimals.
+func (ctl *AppController) getFuturesCollateral(ctx context.Context) (common.Address, uint8, error) {
+	baseCurrency, err := ctl.getFuturesBaseCurrency(ctx)
+	if err != nil {
+		return common.Address{}, 0, err
+	}
+
+	// stablecoins used as collateral often have 6 decimals, so never assume 18
+	decimals, err := ctl.ethCore.TokenDecimals(ctx, baseCurrency)
+	if err != nil {
+		err = errors.Wrap(err, "failed to get decimals of base currency")
+		return common.Address{}, 0, err
+	}
+
+	return baseCurrency, decimals, nil
+}
+
+// getFuturesBaseCurrency returns address of the token used as collateral by derivatives markets.
+func (ctl *AppController) getFuturesBaseCurrency(ctx context.Context) (common.Address, error) {
+	markets, err := ctl.restClient.DerivativeMarkets(ctx)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	var baseCurrency common.Address
+	for _, market := range markets {
+		if !common.IsHexAddress(market.BaseCurrency) {
+			continue
+		}
+
+		// the Futures contract keeps a single margin balance, so markets must share the collateral
+		marketBaseCurrency := common.HexToAddress(market.BaseCurrency)
+		if baseCurrency == (common.Address{}) {
+			baseCurrency = marketBaseCurrency
+		} else if marketBaseCurrency != baseCurrency {
+			err := errors.Errorf("derivatives markets use different base currencies: %s and %s",
+				baseCurrency.Hex(), marketBaseCurrency.Hex())
+			return common.Address{}, err
+		}
+	}
+
+	if baseCurrency == (common.Address{}) {
+		return common.Address{}, errors.New("no derivatives markets with base currency")
+	}
+
+	return baseCurrency, nil
+}
+
+func (ctl *AppController) getDerivativesMarket(ctx context.Context, ticker string) (*restAPI.DerivativeMarket, error) {
+	markets, err := ctl.restClient.DerivativeMarkets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, market := range markets {
+		if market.Ticker == ticker && market.Enabled {
+			return market, nil
+		}
+	}
+
+	return nil, errors.Errorf("market not found: %s", ticker)
+}
+
+// annotateDerivativeOrders fetches states of derivatives orders from the Futures contract,
+// and stores the fillable quantity and order status into the metadata of each record.
+func (ctl *AppController) annotateDerivativeOrders(ctx context.Context, records []*sdaAPI.DerivativeOrderRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	orders := make([]wrappers.Order, len(records))
+	signatures := make([][]byte, len(records))
+	for idx, record := range records {
+		orders[idx], signatures[idx] = do2wo(record.DerivativeOrder)
+	}
+
+	states, err := ctl.ethCore.GetOrderRelevantStates(ctx, orders, signatures)
+	if err != nil {
+		err = errors.Wrap(err, "failed to get order states")
+		return err
+	}
+
+	for idx, fillable := range states.FillableTakerAssetAmounts {
+		if records[idx].MetaData == nil {
+			records[idx].MetaData = make(map[string]string)
+		}
+
+		records[idx].MetaData["remainingTakerAssetAmount"] = fillable.String()
+		if !states.IsValidSignature[idx] {
+			records[idx].MetaData["remainingTakerAssetAmount"] = "0"
+		}
+
+		records[idx].MetaData["orderStatus"] = derivativeOrderStatus[states.OrdersInfo[idx].OrderStatus]
+	}
+
+	return nil
+}
+
+// isLongDerivativeOrder checks whether the order is a long one, i.e. it has
+// market ID set as the maker asset data. Short orders have it as the taker asset.
+func isLongDerivativeOrder(o *sdaAPI.DerivativeOrder) bool {
+	makerAssetData := common.FromHex(o.MakerAssetData)
+	return !bytes.Equal(makerAssetData, make([]byte, len(makerAssetData)))
+}
+
+func isDerivativeMakerOf(order *sdaAPI.DerivativeOrder, address common.Address) bool {
+	return common.HexToAddress(order.MakerAddress) == address
+}
+
+func derivativeOrderHash(o *sdaAPI.DerivativeOrder) common.Hash {
+	zxOrder, err := do2zo(o)
+	if err != nil {
+		return common.Hash{}
+	}
+
+	orderHash, _ := zxOrder.ComputeOrderHash()
+	return orderHash
+}
+
+type TradeOrderbookArgs struct {
+	Market string
+}
+
+func (ctl *AppController) ActionTradeOrderbook(args interface{}) {
+	orderbookArgs := args.(*TradeOrderbookArgs)
+
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	bids, asks, err := ctl.sraClient.Orderbook(ctx, orderbookArgs.Market)
+	if err != nil {
+		logrus.WithField("tradePair", orderbookArgs.Market).
+			WithError(err).Errorln("unable to get orderbook for trade pair")
+		return
+	}
+
+	bidOrders := make([]wrappers.Order, len(bids))
+	bidSignatures := make([][]byte, len(bids))
+
+	for idx, bid := range bids {
+		bidOrders[idx], bidSignatures[idx] = so2wo(bid.Order)
+	}
+
+	bidStates, err := ctl.ethCore.GetZeroExOrderRelevantStates(ctx, bidOrders, bidSignatures)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+	orderStatus := map[uint8]string{
+		0: "INVALID",
+		1: "INVALID_MAKER_ASSET_AMOUNT",
+		2: "INVALID_TAKER_ASSET_AMOUNT",
+		3: "FILLABLE",
+		4: "EXPIRED",
+		5: "FULLY_FILLED",
+		6: "CANCELLED",
+	}
+
+	for idx, fillable := range bidStates.FillableTakerAssetAmounts {
+		// TODO: (@Maxim) see why fillable is not correct
+		bids[idx].MetaData["filled"] = bidStates.OrdersInfo[idx].OrderTakerAssetFilledAmount.String()
+		bids[idx].MetaData["fillableTakerAssetAmount"] = bids[idx].MetaData["remainingTakerAssetAmount"]
+		bids[idx].MetaData["fillable"] = decimal.RequireFromString(fillable.String()).Shift(-18).StringFixed(5)
+		if bidStates.IsValidSignature[idx] == false {
+			bids[idx].MetaData["fillable"] = decimal.RequireFromString("0").Shift(-18).StringFixed(5)
+		}
+		price, _ := calcOrderPrice(bids[idx].Order, bids[idx].MetaData["remainingTakerAssetAmount"], true)
+		bids[idx].MetaData["price"] = price.StringFixed(9)
+		if orderStatus[bidStates.OrdersInfo[idx].OrderStatus] == "FULLY_FILLED" {
+			bids[idx].MetaData["notes"] = " " + decimal.RequireFromString(bids[idx].Order.TakerAssetAmount).Shift(-18).StringFixed(5) + " " + orderStatus[bidStates.OrdersInfo[idx].OrderStatus]
+		} else {
+			bids[idx].MetaData["notes"] = " " + bids[idx].MetaData["fillable"] + "/" + decimal.RequireFromString(bids[idx].Order.TakerAssetAmount).Shift(-18).StringFixed(5) + " remaining " + orderStatus[bidStates.OrdersInfo[idx].OrderStatus]
+		}
+
+	}
+
+	askOrders := make([]wrappers.Order, len(asks))
+	askSignatures := make([][]byte, len(asks))
+
+	for idx, ask := range asks {
+		askOrders[idx], askSignatures[idx] = so2wo(ask.Order)
+	}
+
+	askStates, err := ctl.ethCore.GetZeroExOrderRelevantStates(ctx, askOrders, askSignatures)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+
+	for idx, fillable := range askStates.FillableTakerAssetAmounts {
+		asks[idx].MetaData["fillableTakerAssetAmount"] = asks[idx].MetaData["remainingTakerAssetAmount"]
+		// TODO: (@Maxim) see why fillable is not correct
+		asks[idx].MetaData["fillable"] = decimal.RequireFromString(fillable.String()).Shift(-18).StringFixed(5)
+		if askStates.IsValidSignature[idx] == false {
+			asks[idx].MetaData["fillable"] = decimal.RequireFromString("0").Shift(-18).StringFixed(5)
+		}
+		price, _ := calcOrderPrice(asks[idx].Order, asks[idx].MetaData["remainingTakerAssetAmount"], false)
+		asks[idx].MetaData["price"] = price.StringFixed(9)
+		if orderStatus[askStates.OrdersInfo[idx].OrderStatus] == "FULLY_FILLED" {
+			asks[idx].MetaData["notes"] = " " + decimal.RequireFromString(asks[idx].Order.TakerAssetAmount).Shift(-18).StringFixed(5) + " " + orderStatus[askStates.OrdersInfo[idx].OrderStatus]
+		} else {
+			asks[idx].MetaData["notes"] = " " + asks[idx].MetaData["fillable"] + "/" + decimal.RequireFromString(asks[idx].Order.TakerAssetAmount).Shift(-18).StringFixed(5) + " remaining " + orderStatus[askStates.OrdersInfo[idx].OrderStatus]
+		}
+	}
+
+	pair := strings.Split(orderbookArgs.Market, "/")
+	baseAsset := pair[0]
+	quoteAsset := pair[1]
+
+	table := termtables.CreateTable()
+	table.UTF8Box()
+	table.AddTitle("ORDERBOOK")
+	table.AddHeaders(
+		fmt.Sprintf("Price (%s)", quoteAsset),
+		fmt.Sprintf("Amount (%s)", baseAsset),
+		"Notes",
+	)
+	zero := decimal.RequireFromString("0").Shift(-18).StringFixed(5)
+	sort.Slice(asks, func(i, j int) bool {
+		if asks[i].MetaData["fillable"] == zero {
+			return true
+		} else if asks[j].MetaData["fillable"] == zero {
+			return false
+		}
+		return decimal.RequireFromString(asks[i].MetaData["price"]).GreaterThan(decimal.RequireFromString(asks[j].MetaData["price"]))
+	})
+
+	sort.Slice(bids, func(i, j int) bool {
+		if bids[i].MetaData["fillable"] == zero {
+			return false
+		} else if bids[j].MetaData["fillable"] == zero {
+			return true
+		}
+		return decimal.RequireFromString(bids[i].MetaData["price"]).GreaterThan(decimal.RequireFromString(bids[j].MetaData["price"]))
+	})
+	if len(asks) == 0 {
+		table.AddRow(color.RedString("No asks."), "", "")
+	} else {
+		for _, ask := range asks {
+			var notes string
+			if isMakerOf(ask.Order, defaultAccount) {
+				notes = "⭑ " + ctl.ownerMark(defaultAccount)
+			}
+
+			notes += ask.MetaData["notes"]
+			table.AddRow(
+				color.RedString("%s", ask.MetaData["price"]),
+				color.RedString("%s", ask.MetaData["fillable"]),
+				notes,
+			)
+		}
+	}
+
+	table.AddSeparator()
+
+	if len(bids) == 0 {
+		table.AddRow(color.GreenString("No bids."), "", "")
+	} else {
+		for _, bid := range bids {
+			var notes string
+			if isMakerOf(bid.Order, defaultAccount) {
+				notes = "⭑ " + ctl.ownerMark(defaultAccount)
+			}
+
+			notes += bid.MetaData["notes"]
+
+			price, _ := calcOrderPrice(bid.Order, bid.MetaData["remainingTakerAssetAmount"], true)
+			makerAmount := decimal.RequireFromString(bid.Order.MakerAssetAmount)
+			takerAmount := decimal.RequireFromString(bid.Order.TakerAssetAmount)
+			fillable := decimal.RequireFromString(bid.MetaData["remainingTakerAssetAmount"])
+			filled := decimal.RequireFromString(bid.MetaData["filled"])
+			fmt.Println(makerAmount.Shift(-18).StringFixed(5), takerAmount.Shift(-18).StringFixed(5), fillable.Shift(-18).StringFixed(5), filled.Shift(-18).StringFixed(5))
+			// (takerAmount - fillable) / takerAmount * makerAmount
+
+			table.AddRow(
+				color.GreenString("%s", price.StringFixed(9)),
+				color.GreenString("%s", (takerAmount.Sub(fillable)).Mul(makerAmount).Div(takerAmount).Shift(-18).StringFixed(5)),
+				notes,
+			)
+		}
+	}
+
+	fmt.Println(table.Render())
+}
+
+func isMakerOf(order *sraAPI.Order, address common.Address) bool {
+	return bytes.Compare(
+		common.HexToAddress(order.MakerAddress).Bytes(),
+		address.Bytes(),
+	) == 0
+}
+
+func (ctl *AppController) getTokenNamesAndAssets(ctx context.Context) (tokenNames []string, assets []common.Address, err error) {
+	if ctl.sraClient == nil {
+		err := errors.New("client in offline mode")
+		return nil, nil, err
+	}
+
+	pairs, err := ctl.restClient.TradePairs(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "failed to list trade pairs")
+		return tokenNames, assets, err
+	}
+
+	markets, err := ctl.restClient.DerivativeMarkets(ctx)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to fetch trade pairs")
+		return
+	}
+
+	tokenMap := make(map[string]common.Address, len(pairs)+len(markets))
+
+	for _, pair := range pairs {
+		parts := strings.Split(pair.Name, "/")
+		if len(parts) != 2 {
+			continue
+		}
+
+		tokenMap[parts[0]] = common.HexToAddress("0x" + pair.MakerAssetData[len(pair.MakerAssetData)-40:])
+		tokenMap[parts[1]] = common.HexToAddress("0x" + pair.TakerAssetData[len(pair.TakerAssetData)-40:])
+	}
+
+	for _, market := range markets {
+		tokenMap[market.Ticker] = common.HexToAddress(market.BaseCurrency)
+	}
+
+	if ctl.ethCore != nil {
+		// always override WETH with client-side configured address
+		tokenMap["WETH"] = ctl.ethCore.ContractAddress(ethcore.EthContractWETH9)
+	}
+
+	tokenNames = make([]string, 0, len(tokenMap))
+	for name := range tokenMap {
+		tokenNames = append(tokenNames, name)
+	}
+
+	sort.Strings(tokenNames)
+
+	assets = make([]common.Address, 0, len(tokenNames))
+
+	for _, name := range tokenNames {
+		assets = append(assets, tokenMap[name])
+	}
+
+	return tokenNames, assets, nil
+}
+
+func (ctl *AppController) ActionTradeTokens() {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
+	if err == clients.ErrClientUnavailable {
+		logrus.Errorln("Ethereum client is not initialized")
+		return
+	} else if err != nil {
+		logrus.Errorln(err)
+		return
+	}
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	ethBalance, err := ctl.ethCore.EthBalance(ctx, defaultAccount)
+	if err != nil {
+		logrus.WithError(err).WithField("account", defaultAccount.Hex()).Warningln("Unable to get Eth balance")
+	}
+
+	var ethBalanceStr string = "-"
+	if ethBalance != nil {
+		ethBalanceDec := decimal.NewFromBigInt(ethBalance, 0)
+		ethBalanceDec = ethBalanceDec.Div(decimal.New(1, 18))
+		ethBalanceStr = ethBalanceDec.StringFixed(8)
+	}
+
+	networkName := ctl.activeNetwork()
+	proxyAddressHex := ctl.mustConfigValue(fmt.Sprintf("networks.%s.erc20proxy_address", networkName))
+
+	allowances := ctl.ethCore.AllowancesMap(ctx, defaultAccount, common.HexToAddress(proxyAddressHex), assets)
+	balances := ctl.ethCore.BalancesMap(ctx, defaultAccount, assets)
+
+	if len(balances) == 0 && len(allowances) == 0 {
+		fmt.Println("No token info available.")
+		return
+	}
+
+	table := termtables.CreateTable()
+	table.UTF8Box()
+	table.AddTitle(
+		fmt.Sprintf("Account %s (%s ETH); Allowance To: %s", ctl.formatAccount(defaultAccount), ethBalanceStr, proxyAddressHex),
+	)
+	table.AddHeaders("Token", "Address", "Balance", "Unlocked")
+
+	for idx, name := range tokenNames {
+		addr := assets[idx]
+
+		var balanceStr string = "-"
+		var unlockedStr string = " "
+
+		if balances[addr] != nil {
+			balanceDec := decimal.NewFromBigInt(balances[addr], 0)
+			balanceDec = balanceDec.Div(decimal.New(1, 18))
+			balanceStr = balanceDec.StringFixed(8)
+		}
+
+		if allowances[addr] != nil {
+			if ethcore.IsUnlimitedAllowance(allowances[addr]) {
+				unlockedStr = "x"
+			}
+		}
+
+		table.AddRow(
+			name,
+			addr.Hex(),
+			balanceStr,
+			fmt.Sprintf("[%s]", unlockedStr),
+		)
+	}
+
+	fmt.Println(table.Render())
+}
+
+func (ctl *AppController) ActionTradePairs() {
+	ctx := context.Background()
+
+	pairs, err := ctl.restClient.TradePairs(ctx)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to list trade pairs")
+		return
+	}
+
+	for idx, pair := range pairs {
+		fmt.Printf("%d) %s\n", idx+1, pair.Name)
+	}
+}
+
+type UtilTokenLockArgs struct {
+	TokenName string
+	Password  string
+}
+
+func (ctl *AppController) ActionUtilLock(args interface{}) {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
+	if err == clients.ErrClientUnavailable {
+		logrus.Errorln("Ethereum client is not initialized")
+		return
+	} else if err != nil {
+		logrus.Errorln(err)
+		return
+	}
+
+	tokenLockArgs := args.(*UtilTokenLockArgs)
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: tokenLockArgs.Password,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	var asset common.Address
+	tokenName := strings.ToLower(tokenLockArgs.TokenName)
+	for idx, name := range tokenNames {
+		if strings.ToLower(name) == tokenName {
+			asset = assets[idx]
+			break
+		}
+	}
+
+	txHash, err := ctl.ethCore.TokenLock(callArgs, asset)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to lock token")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+}
+
+type UtilTokenUnlockArgs struct {
+	TokenName string
+	Password  string
+}
+
+func (ctl *AppController) ActionUtilUnlock(args interface{}) {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
+	if err == clients.ErrClientUnavailable {
+		logrus.Errorln("Ethereum client is not initialized")
+		return
+	} else if err != nil {
+		logrus.Errorln(err)
+		return
+	}
+
+	tokenUnlockArgs := args.(*UtilTokenUnlockArgs)
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: tokenUnlockArgs.Password,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	var asset common.Address
+	tokenName := strings.ToLower(tokenUnlockArgs.TokenName)
+	for idx, name := range tokenNames {
+		if strings.ToLower(name) == tokenName {
+			asset = assets[idx]
+			break
+		}
+	}
+
+	txHash, err := ctl.ethCore.TokenUnlock(callArgs, asset)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to unlock token")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+}
+
+type UtilWrapArgs struct {
+	Amount   string
+	Password string
+}
+
+func (ctl *AppController) ActionUtilWrap(args interface{}) {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	ethWrapArgs := args.(*UtilWrapArgs)
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: ethWrapArgs.Password,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	var amount *big.Int
+
+	amountDec, err := decimal.NewFromString(ethWrapArgs.Amount)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to parse amount")
+		return
+	} else if amountDec.LessThan(decimal.RequireFromString("0.0000001")) {
+		logrus.Errorln("amount is too small, must be at least 0.0000001 ETH")
+		return
+	} else {
+		amount = dec2big(amountDec)
+	}
+
+	txHash, err := ctl.ethCore.EthWrap(callArgs, amount)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to wrap ETH")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+}
+
+type UtilUnwrapArgs struct {
+	Amount   string
+	Password string
+}
+
+func (ctl *AppController) ActionUtilUnwrap(args interface{}) {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	ethUnwrapArgs := args.(*UtilUnwrapArgs)
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: ethUnwrapArgs.Password,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	var amount *big.Int
+
+	amountDec, err := decimal.NewFromString(ethUnwrapArgs.Amount)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to parse amount")
+		return
+	} else if amountDec.LessThan(decimal.RequireFromString("0.0000001")) {
+		logrus.Errorln("amount is too small, must be at least 0.0000001 WETH")
+		return
+	} else {
+		amount = dec2big(amountDec)
+	}
+
+	txHash, err := ctl.ethCore.EthUnwrap(callArgs, amount)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to wrap WETH")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+}
+
+type UtilSendArgs struct {
+	To           string
+	Amount       string
+	SignPassword string
+}
+
+func (ctl *AppController) ActionUtilSend(args interface{}) {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	sendArgs := args.(*UtilSendArgs)
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	to, err := ctl.resolveTransferRecipient(sendArgs.To, defaultAccount)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to send ETH")
+		return
+	}
+
+	amount, err := parseTokenAmount(sendArgs.Amount, 18)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to parse amount")
+		return
+	}
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: sendArgs.SignPassword,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	estimate, err := ctl.ethCore.EstimateEthSend(callArgs, to, amount)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to send ETH")
+		return
+	}
+
+	fmt.Printf("Sending %s ETH to %s\n", formatTokenAmount(amount, 18), ctl.formatAccount(to))
+	fmt.Println(formatTransferEstimate(estimate))
+
+	if !confirmAction("Confirm the transfer?") {
+		return
+	}
+
+	txHash, err := ctl.ethCore.EthSend(callArgs, to, amount, estimate)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to send ETH")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+}
+
+type UtilTransferArgs struct {
+	TokenName    string
+	To           string
+	Amount       string
+	SignPassword string
+}
+
+func (ctl *AppController) ActionUtilTransfer(args interface{}) {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
+	if err == clients.ErrClientUnavailable {
+		logrus.Errorln("Ethereum client is not initialized")
+		return
+	} else if err != nil {
+		logrus.Errorln(err)
+		return
+	}
+
+	transferArgs := args.(*UtilTransferArgs)
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	tokenName, asset, ok := findToken(tokenNames, assets, transferArgs.TokenName)
+	if !ok {
+		logrus.Errorf("unknown token: %s", transferArgs.TokenName)
+		return
+	}
+
+	to, err := ctl.resolveTransferRecipient(transferArgs.To, defaultAccount)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to transfer tokens")
+		return
+	}
+
+	decimals, err := ctl.ethCore.TokenDecimals(ctx, asset)
+	if err != nil {
+		logrus.WithError(err).Errorf("unable to get decimals of %s", tokenName)
+		return
+	}
+
+	amount, err := parseTokenAmount(transferArgs.Amount, decimals)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to parse amount")
+		return
+	}
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: transferArgs.SignPassword,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	estimate, err := ctl.ethCore.EstimateTokenTransfer(callArgs, asset, to, amount)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to transfer tokens")
+		return
+	}
+
+	fmt.Printf("Transferring %s %s to %s\n", formatTokenAmount(amount, decimals), tokenName, ctl.formatAccount(to))
+	fmt.Println(formatTransferEstimate(estimate))
+
+	if !confirmAction("Confirm the transfer?") {
+		return
+	}
+
+	txHash, err := ctl.ethCore.TokenTransfer(callArgs, asset, to, amount, estimate)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to transfer tokens")
+		return
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+	ctl.checkTx(txHash)
+}
+
+// resolveTransferRecipient resolves the recipient address or label, refusing transfers
+// that would burn funds or go nowhere.
+func (ctl *AppController) resolveTransferRecipient(addressOrLabel string, from common.Address) (common.Address, error) {
+	to, err := ctl.resolveAccount(addressOrLabel)
+	if err != nil {
+		return common.Address{}, err
+	} else if to == (common.Address{}) {
+		return common.Address{}, errors.New("recipient is the zero address")
+	} else if to == from {
+		return common.Address{}, errors.New("recipient is the sending account")
+	}
+
+	return to, nil
+}
+
+// confirmAction asks a yes/no question, anything but yes cancels the action.
+func confirmAction(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		logrus.WithError(err).Warningln("failed to read input")
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+
+	logrus.Warningln("Cancelled.")
+	return false
+}
+
+// parseTokenAmount converts amount in token units into the integer amount, as stored on-chain.
+func parseTokenAmount(amount string, decimals uint8) (*big.Int, error) {
+	amountDec, err := decimal.NewFromString(strings.TrimSpace(amount))
+	if err != nil {
+		return nil, err
+	} else if !amountDec.IsPositive() {
+		return nil, errors.New("amount must be positive")
+	}
+
+	baseUnits := amountDec.Shift(int32(decimals))
+	if !baseUnits.Equal(baseUnits.Truncate(0)) {
+		return nil, errors.Errorf("amount has more than %d decimal places", decimals)
+	}
+
+	v, _ := big.NewInt(0).SetString(baseUnits.String(), 10)
+
+	return v, nil
+}
+
+// formatTokenAmount renders the on-chain integer amount in token units.
+func formatTokenAmount(amount *big.Int, decimals uint8) string {
+	return decimal.NewFromBigInt(amount, -int32(decimals)).String()
+}
+
+func formatTransferEstimate(estimate *ethcore.TransferEstimate) string {
+	return fmt.Sprintf("Gas estimate: %d gas at %s Gwei, max fee %s ETH",
+		estimate.Gas,
+		decimal.NewFromBigInt(estimate.GasPrice, -9).String(),
+		formatTokenAmount(estimate.Fee(), 18),
+	)
+}
+
+// findToken looks up a token by its name, ignoring case.
+func findToken(tokenNames []string, assets []common.Address, tokenName string) (string, common.Address, bool) {
+	tokenName = strings.TrimSpace(tokenName)
+
+	for idx, name := range tokenNames {
+		if strings.EqualFold(name, tokenName) {
+			return name, assets[idx], true
+		}
+	}
+
+	return "", common.Address{}, false
+}
+
+// tokenDecimals returns decimals of the token, assuming 18 if the token doesn't tell.
+func (ctl *AppController) tokenDecimals(ctx context.Context, asset common.Address) uint8 {
+	decimals, err := ctl.ethCore.TokenDecimals(ctx, asset)
+	if err != nil {
+		logrus.WithError(err).Debugf("unable to get decimals of %s, assuming 18", asset.Hex())
+		return 18
+	}
+
+	return decimals
+}
+
+func formatAllowance(allowance *big.Int, decimals uint8) string {
+	if ethcore.IsUnlimitedAllowance(allowance) {
+		return "unlimited"
+	}
+
+	return formatTokenAmount(allowance, decimals)
+}
+
+// resolveSpender resolves the spender address or label, ERC20Proxy is used by default.
+func (ctl *AppController) resolveSpender(addressOrLabel string) (common.Address, error) {
+	if isEmpty(addressOrLabel) {
+		return ctl.ethCore.ContractAddress(ethcore.EthContractERC20Proxy), nil
+	}
+
+	return ctl.resolveAccount(addressOrLabel)
+}
+
+func (ctl *AppController) formatSpender(spender common.Address) string {
+	if spender == ctl.ethCore.ContractAddress(ethcore.EthContractERC20Proxy) {
+		return fmt.Sprintf("ERC20Proxy (%s)", spender.Hex())
+	}
+
+	return ctl.formatAccount(spender)
+}
+
+type UtilAllowancesArgs struct {
+	Spender string
+}
+
+func (ctl *AppController) ActionUtilAllowances(args interface{}) {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
+	if err == clients.ErrClientUnavailable {
+		logrus.Errorln("Ethereum client is not initialized")
+		return
+	} else if err != nil {
+		logrus.Errorln(err)
+		return
+	}
+
+	spender, err := ctl.resolveSpender(args.(*UtilAllowancesArgs).Spender)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to list allowances")
+		return
+	}
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	allowances := ctl.ethCore.AllowancesMap(ctx, defaultAccount, spender, assets)
+
+	if len(allowances) == 0 {
+		fmt.Println("No token info available.")
+		return
+	}
+
+	table := termtables.CreateTable()
+	table.UTF8Box()
+	table.AddTitle(
+		fmt.Sprintf("Account %s; Allowance To: %s", ctl.formatAccount(defaultAccount), ctl.formatSpender(spender)),
+	)
+	table.AddHeaders("Token", "Address", "Allowance")
+
+	for idx, name := range tokenNames {
+		addr := assets[idx]
+
+		var allowanceStr string = "-"
+		if allowances[addr] != nil {
+			allowanceStr = formatAllowance(allowances[addr], ctl.tokenDecimals(ctx, addr))
+		}
+
+		table.AddRow(
+			name,
+			addr.Hex(),
+			allowanceStr,
+		)
+	}
+
+	fmt.Println(table.Render())
+}
+
+type UtilAllowanceArgs struct {
+	TokenName string
+	Spender   string
+	Amount    string
+	Password  string
+}
+
+func (ctl *AppController) ActionUtilAllowance(args interface{}) {
+	allowanceArgs := args.(*UtilAllowanceArgs)
+
+	ctl.setAllowance(allowanceArgs.TokenName, allowanceArgs.Spender, allowanceArgs.Amount, allowanceArgs.Password)
+}
+
+type UtilRevokeArgs struct {
+	TokenName string
+	Spender   string
+	Password  string
+}
+
+func (ctl *AppController) ActionUtilRevoke(args interface{}) {
+	revokeArgs := args.(*UtilRevokeArgs)
+
+	ctl.setAllowance(revokeArgs.TokenName, revokeArgs.Spender, "0", revokeArgs.Password)
+}
+
+// setAllowance approves the exact amount of tokens to the spender, zero amount revokes the approval.
+func (ctl *AppController) setAllowance(tokenName, spenderAddressOrLabel, amountStr, password string) {
+	ctx := context.Background()
+	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelFn()
+
+	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
+	if err == clients.ErrClientUnavailable {
+		logrus.Errorln("Ethereum client is not initialized")
+		return
+	} else if err != nil {
+		logrus.Errorln(err)
+		return
+	}
+
+	name, asset, ok := findToken(tokenNames, assets, tokenName)
+	if !ok {
+		logrus.Errorf("unknown token: %s", tokenName)
+		return
+	}
+	tokenName = name
+
+	spender, err := ctl.resolveSpender(spenderAddressOrLabel)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to set allowance")
+		return
+	}
+
+	decimals := ctl.tokenDecimals(ctx, asset)
+
+	amount := big.NewInt(0)
+	if amountDec, err := decimal.NewFromString(strings.TrimSpace(amountStr)); err != nil || !amountDec.IsZero() {
+		if amount, err = parseTokenAmount(amountStr, decimals); err != nil {
+			logrus.WithError(err).Errorln("failed to parse amount")
+			return
+		}
+	}
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	prevAllowance, err := ctl.ethCore.Allowance(ctx, defaultAccount, spender, asset)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to get current allowance")
+		return
+	} else if prevAllowance.Cmp(amount) == 0 {
+		logrus.Infof("Allowance of %s to %s is already %s", tokenName, ctl.formatSpender(spender), formatAllowance(amount, decimals))
+		return
+	}
+
+	fmt.Printf("Allowance of %s to %s: %s -> %s\n",
+		tokenName,
+		ctl.formatSpender(spender),
+		formatAllowance(prevAllowance, decimals),
+		formatAllowance(amount, decimals),
+	)
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: password,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	if err := ctl.approveAllowance(callArgs, asset, spender, prevAllowance, amount); err != nil {
+		logrus.WithError(err).Errorln("unable to set allowance")
+	}
+}
+
+// approveAllowance changes the allowance and waits for txs to be mined. Changing one
+// non-zero allowance to another lets the spender front-run and use both of them,
+// so the allowance is reset to zero first.
+func (ctl *AppController) approveAllowance(
+	callArgs *ethcore.CallArgs,
+	asset, spender common.Address,
+	prevAllowance, amount *big.Int,
+) error {
+	if prevAllowance.Sign() > 0 && amount.Sign() > 0 {
+		logrus.Infoln("Resetting the allowance to 0 before setting a new one")
+
+		txHash, err := ctl.ethCore.Approve(callArgs, asset, spender, big.NewInt(0))
+		if err != nil {
+			err = errors.Wrap(err, "failed to reset allowance")
+			return err
+		}
+
+		fmt.Println(ctl.formatTxLink(txHash))
+
+		if err := ctl.awaitTxWithSpin(txHash, "resetting allowance"); err != nil {
+			err = errors.Wrap(err, "allowance reset is not confirmed, set the new allowance once it is")
+			return err
+		}
+	}
+
+	txHash, err := ctl.ethCore.Approve(callArgs, asset, spender, amount)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ctl.formatTxLink(txHash))
+
+	if err := ctl.awaitTxWithSpin(txHash, "approving"); err != nil {
+		err = errors.Wrap(err, "approval is not confirmed")
+		return err
+	}
+
+	return nil
+}
+
+type UtilApprovalsArgs struct {
+	Tokens string
+	Blocks string
+}
+
+func (ctl *AppController) ActionUtilApprovals(args interface{}) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelFn()
+
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	approvalsArgs := args.(*UtilApprovalsArgs)
+
+	approvals, tokenLabels, ok := ctl.scanApprovals(ctx, defaultAccount, approvalsArgs.Tokens, approvalsArgs.Blocks)
+	if !ok {
+		return
+	} else if len(approvals) == 0 {
+		fmt.Printf("No active approvals found for %s.\n", ctl.formatAccount(defaultAccount))
+		return
+	}
+
+	table := termtables.CreateTable()
+	table.UTF8Box()
+	table.AddTitle(fmt.Sprintf("Approvals of %s", ctl.formatAccount(defaultAccount)))
+	table.AddHeaders("Token", "Spender", "Allowance")
+
+	for _, approval := range approvals {
+		table.AddRow(
+			tokenLabels[approval.Asset],
+			ctl.formatSpender(approval.Spender),
+			formatAllowance(approval.Allowance, ctl.tokenDecimals(ctx, approval.Asset)),
+		)
+	}
+
+	fmt.Println(table.Render())
+}
+
+type UtilRevokeAllArgs struct {
+	Tokens   string
+	Spender  string
+	Blocks   string
+	Password string
+}
+
+func (ctl *AppController) ActionUtilRevokeAll(args interface{}) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelFn()
+
+	revokeAllArgs := args.(*UtilRevokeAllArgs)
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	var spender *common.Address
+	if !isEmpty(revokeAllArgs.Spender) {
+		addr, err := ctl.resolveAccount(revokeAllArgs.Spender)
+		if err != nil {
+			logrus.WithError(err).Errorln("unable to revoke approvals")
+			return
+		}
+
+		spender = &addr
+	}
+
+	approvals, tokenLabels, ok := ctl.scanApprovals(ctx, defaultAccount, revokeAllArgs.Tokens, revokeAllArgs.Blocks)
+	if !ok {
+		return
+	}
+
+	toRevoke := make([]*ethcore.TokenApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		if spender != nil && approval.Spender != *spender {
+			continue
+		}
+
+		toRevoke = append(toRevoke, approval)
+	}
+
+	if len(toRevoke) == 0 {
+		fmt.Println("Nothing to revoke.")
+		return
+	}
+
+	for _, approval := range toRevoke {
+		fmt.Printf("Revoking %s allowance to %s\n", tokenLabels[approval.Asset], ctl.formatSpender(approval.Spender))
+	}
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     defaultAccount,
+		FromPass: revokeAllArgs.Password,
+		GasPrice: ctl.ethGasPrice,
+	}
+
+	txHashes, err := ctl.ethCore.RevokeApprovals(callArgs, toRevoke)
+	for _, txHash := range txHashes {
+		fmt.Println(ctl.formatTxLink(txHash))
+	}
+
+	if err != nil {
+		logrus.WithError(err).Errorf("unable to revoke approvals, %d of %d revoke txs sent", len(txHashes), len(toRevoke))
+	}
+
+	for _, txHash := range txHashes {
+		ctl.checkTx(txHash)
+	}
+}
+
+// scanApprovals finds active approvals of the account, on all known tokens and the custom ones,
+// given as a list of addresses. Returns token names to display along with approvals.
+// Approvals are looked up in logs of recent blocks only, nodes answer each logs query
+// of a chunk of blocks in turn and a scan of the whole chain takes too long.
+const (
+	defaultApprovalBlocks = 1000000
+	maxApprovalBlocks     = 3000000
+)
+
+func (ctl *AppController) scanApprovals(
+	ctx context.Context,
+	account common.Address,
+	customTokens string,
+	blocksStr string,
+) (approvals []*ethcore.TokenApproval, tokenLabels map[common.Address]string, ok bool) {
+	blocks := uint64(defaultApprovalBlocks)
+	if !isEmpty(blocksStr) {
+		v, err := strconv.ParseUint(strings.TrimSpace(blocksStr), 10, 64)
+		if err != nil {
+			logrus.WithError(err).Errorln("failed to parse number of blocks")
+			return nil, nil, false
+		} else if v == 0 || v > maxApprovalBlocks {
+			logrus.Errorf("number of blocks must be from 1 to %d", maxApprovalBlocks)
+			return nil, nil, false
+		}
+
+		blocks = v
+	}
+
+	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
+	if err == clients.ErrClientUnavailable {
+		logrus.Errorln("Ethereum client is not initialized")
+		return nil, nil, false
+	} else if err != nil {
+		logrus.Errorln(err)
+		return nil, nil, false
+	}
+
+	tokenLabels = make(map[common.Address]string, len(assets))
+	for idx, asset := range assets {
+		tokenLabels[asset] = tokenNames[idx]
+	}
+
+	for _, token := range strings.Fields(strings.Replace(customTokens, ",", " ", -1)) {
+		if _, _, ok := findToken(tokenNames, assets, token); ok {
+			continue
+		} else if !common.IsHexAddress(token) {
+			logrus.Errorf("custom token must be an address: %s", token)
+			return nil, nil, false
+		}
+
+		asset := common.HexToAddress(token)
+		if _, ok := tokenLabels[asset]; !ok {
+			tokenLabels[asset] = asset.Hex()
+			assets = append(assets, asset)
+		}
+	}
+
+	latestBlock, err := ctl.ethCore.LatestBlockNumber(ctx)
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to get the latest block")
+		return nil, nil, false
+	}
+
+	var fromBlock uint64
+	if latestBlock >= blocks {
+		fromBlock = latestBlock - blocks + 1
+	}
+
+	spinCtx, spinCancelFn := context.WithCancel(ctx)
+	spinDone := makeSpin(spinCtx, fmt.Sprintf("scanning approvals on %d tokens in blocks %d-%d", len(assets), fromBlock, latestBlock))
+
+	approvals, err = ctl.ethCore.Approvals(ctx, account, assets, fromBlock, latestBlock)
+
+	spinCancelFn()
+	<-spinDone
+
+	if err != nil && len(approvals) == 0 {
+		logrus.WithError(err).Errorln("unable to scan approvals")
+		return nil, nil, false
+	} else if err != nil {
+		logrus.WithError(err).Warningln("Scan stopped midway, showing approvals found in recent blocks only")
+	} else {
+		fmt.Printf("Scanned blocks %d-%d, approvals made before are not shown.\n", fromBlock, latestBlock)
 	}
 
-	return tokenNames, assets, nil
+	return approvals, tokenLabels, true
 }
 
-func (ctl *AppController) ActionTradeTokens() {
-	ctx := context.Background()
-	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
-	defer cancelFn()
+const (
+	defaultActivityBlocks = 10000
+	maxActivityBlocks     = 100000
+)
 
-	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
-	if err == clients.ErrClientUnavailable {
+type AccountActivityArgs struct {
+	Address string
+	Blocks  string
+}
+
+func (ctl *AppController) ActionAccountsActivity(args interface{}) {
+	if ctl.ethCore == nil {
 		logrus.Errorln("Ethereum client is not initialized")
 		return
-	} else if err != nil {
-		logrus.Errorln(err)
-		return
 	}
 
-	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	activityArgs := args.(*AccountActivityArgs)
 
-	ethBalance, err := ctl.ethCore.EthBalance(ctx, defaultAccount)
+	account := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	if !isEmpty(activityArgs.Address) {
+		addr, err := ctl.resolveAccount(activityArgs.Address)
+		if err != nil {
+			logrus.WithError(err).Errorln("unable to show activity")
+			return
+		}
+
+		account = addr
+	}
+
+	blocks := uint64(defaultActivityBlocks)
+	if !isEmpty(activityArgs.Blocks) {
+		v, err := strconv.ParseUint(strings.TrimSpace(activityArgs.Blocks), 10, 64)
+		if err != nil {
+			logrus.WithError(err).Errorln("failed to parse number of blocks")
+			return
+		} else if v == 0 || v > maxActivityBlocks {
+			logrus.Errorf("number of blocks must be from 1 to %d", maxActivityBlocks)
+			return
+		}
+
+		blocks = v
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelFn()
+
+	latestBlock, err := ctl.ethCore.LatestBlockNumber(ctx)
 	if err != nil {
-		logrus.WithError(err).WithField("account", defaultAccount.Hex()).Warningln("Unable to get Eth balance")
+		logrus.WithError(err).Errorln("unable to get the latest block")
+		return
 	}
 
-	var ethBalanceStr string = "-"
-	if ethBalance != nil {
-		ethBalanceDec := decimal.NewFromBigInt(ethBalance, 0)
-		ethBalanceDec = ethBalanceDec.Div(decimal.New(1, 18))
-		ethBalanceStr = ethBalanceDec.StringFixed(8)
+	var fromBlock uint64
+	if latestBlock > blocks {
+		fromBlock = latestBlock - blocks
 	}
 
-	networkName := ctl.mustConfigValue("networks.default")
-	proxyAddressHex := ctl.mustConfigValue(fmt.Sprintf("networks.%s.erc20proxy_address", networkName))
+	spinCtx, spinCancelFn := context.WithCancel(ctx)
+	spinDone := makeSpin(spinCtx, fmt.Sprintf("scanning blocks %d-%d", fromBlock, latestBlock))
 
-	allowances := ctl.ethCore.AllowancesMap(ctx, defaultAccount, common.HexToAddress(proxyAddressHex), assets)
-	balances := ctl.ethCore.BalancesMap(ctx, defaultAccount, assets)
+	events, err := ctl.ethCore.Activity(ctx, account, fromBlock, latestBlock)
 
-	if len(balances) == 0 && len(allowances) == 0 {
-		fmt.Println("No token info available.")
+	spinCancelFn()
+	<-spinDone
+
+	if err != nil {
+		logrus.WithError(err).Errorln("unable to show activity")
+		return
+	} else if len(events) == 0 {
+		fmt.Printf("No activity of %s in the last %d blocks.\n", ctl.formatAccount(account), blocks)
 		return
 	}
 
+	tokens := ctl.newTokenFormatter(ctx)
+
 	table := termtables.CreateTable()
 	table.UTF8Box()
-	table.AddTitle(
-		fmt.Sprintf("Account %s (%s ETH); Allowance To: %s", defaultAccount.Hex(), ethBalanceStr, proxyAddressHex),
-	)
-	table.AddHeaders("Token", "Address", "Balance", "Unlocked")
+	table.AddTitle(fmt.Sprintf("Activity of %s since block %d", ctl.formatAccount(account), fromBlock))
+	table.AddHeaders("Block", "Event", "Details", "Tx")
 
-	for idx, name := range tokenNames {
-		addr := assets[idx]
+	for _, ev := range events {
+		table.AddRow(
+			ev.BlockNumber,
+			string(ev.Kind),
+			ctl.formatActivityDetails(tokens, ev),
+			ctl.formatTxLink(ev.TxHash),
+		)
+	}
 
-		var balanceStr string = "-"
-		var unlockedStr string = " "
+	fmt.Println(table.Render())
+}
 
-		if balances[addr] != nil {
-			balanceDec := decimal.NewFromBigInt(balances[addr], 0)
-			balanceDec = balanceDec.Div(decimal.New(1, 18))
-			balanceStr = balanceDec.StringFixed(8)
+func (ctl *AppController) formatActivityDetails(tokens *tokenFormatter, ev *ethcore.ActivityEvent) string {
+	switch ev.Kind {
+	case ethcore.ActivityFill:
+		return fmt.Sprintf("sold %s for %s, order %s, with %s",
+			tokens.Amount(ev.Asset, ev.Amount),
+			tokens.Amount(ev.ReceivedAsset, ev.ReceivedAmount),
+			ev.OrderHash.Hex(),
+			ctl.formatAccount(ev.Counterparty),
+		)
+	case ethcore.ActivityCancel:
+		return fmt.Sprintf("cancelled %s/%s order %s",
+			tokens.Name(ev.Asset),
+			tokens.Name(ev.ReceivedAsset),
+			ev.OrderHash.Hex(),
+		)
+	case ethcore.ActivityDeposit:
+		return fmt.Sprintf("wrapped %s ETH into %s", formatTokenAmount(ev.Amount, 18), tokens.Name(ev.Asset))
+	case ethcore.ActivityWithdrawal:
+		return fmt.Sprintf("unwrapped %s into ETH", tokens.Amount(ev.Asset, ev.Amount))
+	case ethcore.ActivityTransfer:
+		if ev.Incoming {
+			return fmt.Sprintf("received %s from %s", tokens.Amount(ev.Asset, ev.Amount), ctl.formatAccount(ev.Counterparty))
 		}
 
-		if allowances[addr] != nil {
-			// is unlocked if allowance > (2^256-1)/2
-			isUnlocked := (allowances[addr].Cmp(ethcore.UnlimitedAllowance.Div(ethcore.UnlimitedAllowance, big.NewInt(2))) == 1)
-			if isUnlocked {
-				unlockedStr = "x"
-			}
+		return fmt.Sprintf("sent %s to %s", tokens.Amount(ev.Asset, ev.Amount), ctl.formatAccount(ev.Counterparty))
+	case ethcore.ActivityApproval:
+		if ev.Amount.Sign() == 0 {
+			return fmt.Sprintf("revoked %s allowance to %s", tokens.Name(ev.Asset), ctl.formatSpender(ev.Counterparty))
 		}
 
-		table.AddRow(
-			name,
-			addr.Hex(),
-			balanceStr,
-			fmt.Sprintf("[%s]", unlockedStr),
+		return fmt.Sprintf("approved %s %s to %s",
+			formatAllowance(ev.Amount, tokens.Decimals(ev.Asset)),
+			tokens.Name(ev.Asset),
+			ctl.formatSpender(ev.Counterparty),
 		)
+	default:
+		return "-"
 	}
+}
 
-	fmt.Println(table.Render())
+// tokenFormatter renders token amounts with names and decimals, looked up once per token.
+type tokenFormatter struct {
+	ctx      context.Context
+	ctl      *AppController
+	names    map[common.Address]string
+	decimals map[common.Address]uint8
 }
 
-func (ctl *AppController) ActionTradePairs() {
-	ctx := context.Background()
+func (ctl *AppController) newTokenFormatter(ctx context.Context) *tokenFormatter {
+	f := &tokenFormatter{
+		ctx:      ctx,
+		ctl:      ctl,
+		names:    make(map[common.Address]string),
+		decimals: make(map[common.Address]uint8),
+	}
 
-	pairs, err := ctl.restClient.TradePairs(ctx)
+	// names are known only with the relayer, fallback to addresses
+	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
 	if err != nil {
-		logrus.WithError(err).Errorln("failed to list trade pairs")
-		return
+		logrus.WithError(err).Debugln("token names are unavailable")
 	}
 
-	for idx, pair := range pairs {
-		fmt.Printf("%d) %s\n", idx+1, pair.Name)
+	for idx, asset := range assets {
+		f.names[asset] = tokenNames[idx]
 	}
-}
 
-type UtilTokenLockArgs struct {
-	TokenName string
-	Password  string
-}
+	if ctl.ethCore != nil {
+		f.names[ctl.ethCore.ContractAddress(ethcore.EthContractWETH9)] = "WETH"
+	}
 
-func (ctl *AppController) ActionUtilLock(args interface{}) {
-	ctx := context.Background()
-	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
-	defer cancelFn()
+	return f
+}
 
-	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
-	if err == clients.ErrClientUnavailable {
-		logrus.Errorln("Ethereum client is not initialized")
-		return
-	} else if err != nil {
-		logrus.Errorln(err)
-		return
+func (f *tokenFormatter) Name(asset common.Address) string {
+	if asset == (common.Address{}) {
+		return "unknown asset"
+	} else if name, ok := f.names[asset]; ok {
+		return name
 	}
 
-	tokenLockArgs := args.(*UtilTokenLockArgs)
-	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	return asset.Hex()
+}
 
-	callArgs := &ethcore.CallArgs{
-		Context:  ctx,
-		From:     defaultAccount,
-		FromPass: tokenLockArgs.Password,
-		GasPrice: ctl.ethGasPrice,
+func (f *tokenFormatter) Decimals(asset common.Address) uint8 {
+	if decimals, ok := f.decimals[asset]; ok {
+		return decimals
 	}
 
-	var asset common.Address
-	tokenName := strings.ToLower(tokenLockArgs.TokenName)
-	for idx, name := range tokenNames {
-		if strings.ToLower(name) == tokenName {
-			asset = assets[idx]
-			break
-		}
+	decimals := f.ctl.tokenDecimals(f.ctx, asset)
+	f.decimals[asset] = decimals
+
+	return decimals
+}
+
+func (f *tokenFormatter) Amount(asset common.Address, amount *big.Int) string {
+	if asset == (common.Address{}) || amount == nil {
+		return f.Name(asset)
 	}
 
-	txHash, err := ctl.ethCore.TokenLock(callArgs, asset)
+	return fmt.Sprintf("%s %s", formatTokenAmount(amount, f.Decimals(asset)), f.Name(asset))
+}
+
+func (ctl *AppController) ActionAccountsUse(args interface{}) {
+	addr, err := ctl.resolveAccount(args.(*ethcore.AccountUseArgs).Address)
 	if err != nil {
-		logrus.WithError(err).Errorln("unable to lock token")
+		logrus.WithError(err).Errorln("failed to select default account")
 		return
 	}
 
-	fmt.Println(ctl.formatTxLink(txHash))
-	ctl.checkTx(txHash)
-}
-
-type UtilTokenUnlockArgs struct {
-	TokenName string
-	Password  string
-}
+	found := false
+	allAccounts := ctl.allAccounts()
 
-func (ctl *AppController) ActionUtilUnlock(args interface{}) {
-	ctx := context.Background()
-	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
-	defer cancelFn()
+	for _, acc := range allAccounts {
+		if bytes.Equal(acc.Bytes(), addr.Bytes()) {
+			found = true
+			break
+		}
+	}
 
-	tokenNames, assets, err := ctl.getTokenNamesAndAssets(ctx)
-	if err == clients.ErrClientUnavailable {
-		logrus.Errorln("Ethereum client is not initialized")
-		return
-	} else if err != nil {
-		logrus.Errorln(err)
+	if !found {
+		logrus.WithField("address", addr.Hex()).Errorln("specified account not found in keystore")
 		return
 	}
 
-	tokenUnlockArgs := args.(*UtilTokenUnlockArgs)
-	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
-
-	callArgs := &ethcore.CallArgs{
-		Context:  ctx,
-		From:     defaultAccount,
-		FromPass: tokenUnlockArgs.Password,
-		GasPrice: ctl.ethGasPrice,
+	if monitored, ok := ctl.riskMonitor.Account(); ok && monitored != addr {
+		ctl.riskMonitor.Stop()
+		logrus.Warningf("Risk monitor of %s stopped, the default account has changed", monitored.Hex())
 	}
 
-	var asset common.Address
-	tokenName := strings.ToLower(tokenUnlockArgs.TokenName)
-	for idx, name := range tokenNames {
-		if strings.ToLower(name) == tokenName {
-			asset = assets[idx]
-			break
-		}
+	prevNetwork := ctl.activeNetwork()
+	ctl.setConfigValue("accounts.default", addr.Hex())
+
+	if err := saveConfig(ctl.configPath, ctl.cfg); err != nil {
+		logrus.WithError(err).Errorln("failed to save config file")
 	}
 
-	txHash, err := ctl.ethCore.TokenUnlock(callArgs, asset)
-	if err != nil {
-		logrus.WithError(err).Errorln("unable to unlock token")
-		return
+	if ctl.ethCore != nil && prevNetwork == ctl.activeNetwork() {
+		ctl.ethCore.SetDefaultFromAddress(addr)
+		ctl.ethGasPrice = ctl.activeGasPrice()
+	} else if err := ctl.initEthClient(); err != nil {
+		logrus.WithError(err).Warningln("failed to init Ethereum client")
 	}
 
-	fmt.Println(ctl.formatTxLink(txHash))
-	ctl.checkTx(txHash)
+	logrus.Infof("Using the default account: %s", ctl.formatAccount(addr))
+
+	if ctl.isWatchOnly(addr) {
+		logrus.Warningln("The account is watch-only, orders and transactions can't be signed")
+	}
 }
 
-type UtilWrapArgs struct {
-	Amount   string
+type AccountUnlockArgs struct {
+	Duration string
 	Password string
 }
 
-func (ctl *AppController) ActionUtilWrap(args interface{}) {
-	ctx := context.Background()
-	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
-	defer cancelFn()
+func (ctl *AppController) ActionAccountsUnlock(args interface{}) {
+	unlockArgs := args.(*AccountUnlockArgs)
 
-	ethWrapArgs := args.(*UtilWrapArgs)
-	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	duration, err := time.ParseDuration(strings.TrimSpace(unlockArgs.Duration))
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to parse session duration")
+		return
+	}
 
-	callArgs := &ethcore.CallArgs{
-		Context:  ctx,
-		From:     defaultAccount,
-		FromPass: ethWrapArgs.Password,
-		GasPrice: ctl.ethGasPrice,
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	if err := ctl.session.Unlock(defaultAccount, unlockArgs.Password, duration); err != nil {
+		logrus.WithError(err).Errorln("failed to unlock account")
+		return
 	}
 
-	var amount *big.Int
+	logrus.Infof("Unlocked %s for %s, orders will be signed without asking the passphrase", ctl.formatAccount(defaultAccount), duration)
+}
 
-	amountDec, err := decimal.NewFromString(ethWrapArgs.Amount)
-	if err != nil {
-		logrus.WithError(err).Errorln("failed to parse amount")
-		return
-	} else if amountDec.LessThan(decimal.RequireFromString("0.0000001")) {
-		logrus.Errorln("amount is too small, must be at least 0.0000001 ETH")
+func (ctl *AppController) ActionAccountsLock() {
+	if !ctl.session.Lock() {
+		logrus.Infoln("No account is unlocked")
 		return
-	} else {
-		amount = dec2big(amountDec)
 	}
 
-	txHash, err := ctl.ethCore.EthWrap(callArgs, amount)
-	if err != nil {
-		logrus.WithError(err).Errorln("unable to wrap ETH")
-		return
+	logrus.Infoln("Account is locked, the cached key has been wiped")
+}
+
+// SessionPassword returns the passphrase of the default account if it's unlocked for a signing session.
+func (ctl *AppController) SessionPassword() (string, bool) {
+	if ctl.DefaultAccountWatchOnly() {
+		return "", false
+	} else if ctl.remoteSigner {
+		// keys are unlocked in the agent or approved by the external signer
+		return "", true
 	}
 
-	fmt.Println(ctl.formatTxLink(txHash))
-	ctl.checkTx(txHash)
+	defaultAccount, ok := ctl.getConfigValue("accounts.default")
+	if !ok {
+		return "", false
+	}
+
+	return ctl.session.Password(common.HexToAddress(defaultAccount))
 }
 
-type UtilUnwrapArgs struct {
-	Amount   string
-	Password string
+// SessionRemaining returns how long the signing session will last, zero if locked.
+func (ctl *AppController) SessionRemaining() time.Duration {
+	if _, ok := ctl.SessionPassword(); !ok {
+		return 0
+	}
+
+	return ctl.session.Remaining()
 }
 
-func (ctl *AppController) ActionUtilUnwrap(args interface{}) {
-	ctx := context.Background()
-	ctx, cancelFn := context.WithTimeout(ctx, 30*time.Second)
-	defer cancelFn()
+type AccountLabelArgs struct {
+	Address string
+	Label   string
+}
 
-	ethUnwrapArgs := args.(*UtilUnwrapArgs)
-	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+func (ctl *AppController) ActionAccountsLabel(args interface{}) {
+	labelArgs := args.(*AccountLabelArgs)
 
-	callArgs := &ethcore.CallArgs{
-		Context:  ctx,
-		From:     defaultAccount,
-		FromPass: ethUnwrapArgs.Password,
-		GasPrice: ctl.ethGasPrice,
+	addr, err := ctl.resolveAccount(labelArgs.Address)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to set account label")
+		return
 	}
 
-	var amount *big.Int
+	label := strings.TrimSpace(labelArgs.Label)
+	if len(label) == 0 {
+		if err := ctl.setAccountSetting(addr, accountSettingLabel, ""); err != nil {
+			logrus.WithError(err).Errorln("failed to remove account label")
+			return
+		}
 
-	amountDec, err := decimal.NewFromString(ethUnwrapArgs.Amount)
-	if err != nil {
-		logrus.WithError(err).Errorln("failed to parse amount")
+		logrus.Infof("Removed label of %s", addr.Hex())
 		return
-	} else if amountDec.LessThan(decimal.RequireFromString("0.0000001")) {
-		logrus.Errorln("amount is too small, must be at least 0.0000001 WETH")
+	} else if strings.ContainsAny(label, " \t") {
+		logrus.Errorln("account label must not contain spaces")
+		return
+	} else if common.IsHexAddress(label) {
+		logrus.Errorln("account label must not look like an address")
+		return
+	} else if labeled, ok := ctl.accountByLabel(label); ok && labeled != addr {
+		logrus.Errorf("label %s is already used by %s", label, labeled.Hex())
 		return
-	} else {
-		amount = dec2big(amountDec)
 	}
 
-	txHash, err := ctl.ethCore.EthUnwrap(callArgs, amount)
-	if err != nil {
-		logrus.WithError(err).Errorln("unable to wrap WETH")
+	if err := ctl.setAccountSetting(addr, accountSettingLabel, label); err != nil {
+		logrus.WithError(err).Errorln("failed to set account label")
 		return
 	}
 
-	fmt.Println(ctl.formatTxLink(txHash))
-	ctl.checkTx(txHash)
+	logrus.Infof("Labeled %s as %s", addr.Hex(), label)
 }
 
-func (ctl *AppController) ActionAccountsUse(args interface{}) {
-	addr, err := ethcore.ParseAccount(args.(*ethcore.AccountUseArgs))
+type AccountSetArgs struct {
+	Address string
+	Setting string
+	Value   string
+}
+
+func (ctl *AppController) ActionAccountsSet(args interface{}) {
+	setArgs := args.(*AccountSetArgs)
+
+	addr, err := ctl.resolveAccount(setArgs.Address)
 	if err != nil {
-		logrus.WithError(err).Errorln("failed to select default account")
+		logrus.WithError(err).Errorln("failed to update account settings")
 		return
 	}
 
-	found := false
-	allAccounts := ctl.keystore.Accounts()
+	value := strings.TrimSpace(setArgs.Value)
 
-	for _, acc := range allAccounts {
-		if bytes.Equal(acc.Bytes(), addr.Bytes()) {
-			found = true
-			break
+	switch setArgs.Setting {
+	case accountSettingNetwork:
+		if len(value) > 0 {
+			if _, ok := ctl.getConfigValue(fmt.Sprintf("networks.%s.endpoint", value)); !ok {
+				logrus.Errorf("network %s not found in config", value)
+				return
+			}
+		}
+	case accountSettingGasPrice:
+		if len(value) > 0 {
+			if gasPrice, ok := big.NewInt(0).SetString(value, 10); !ok || gasPrice.Sign() <= 0 {
+				logrus.Errorln("gas price must be a positive integer in wei")
+				return
+			}
 		}
+	default:
+		logrus.Errorf("unknown account setting: %s", setArgs.Setting)
+		return
 	}
 
-	if !found {
-		logrus.WithField("address", addr.Hex()).Errorln("specified account not found in keystore")
+	prevNetwork := ctl.activeNetwork()
+
+	if err := ctl.setAccountSetting(addr, setArgs.Setting, value); err != nil {
+		logrus.WithError(err).Errorln("failed to update account settings")
 		return
 	}
 
-	ctl.setConfigValue("accounts.default", addr.Hex())
+	if len(value) == 0 {
+		logrus.Infof("Removed %s setting of %s", setArgs.Setting, ctl.formatAccount(addr))
+	} else {
+		logrus.Infof("Set %s = %s for %s", setArgs.Setting, value, ctl.formatAccount(addr))
+	}
 
-	if err := saveConfig(ctl.configPath, ctl.cfg); err != nil {
-		logrus.WithError(err).Errorln("failed to save config file")
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	if addr != defaultAccount {
+		return
 	}
 
-	if ctl.ethCore != nil {
-		ctl.ethCore.SetDefaultFromAddress(addr)
+	if ctl.ethCore != nil && prevNetwork == ctl.activeNetwork() {
+		ctl.ethGasPrice = ctl.activeGasPrice()
 	} else if err := ctl.initEthClient(); err != nil {
 		logrus.WithError(err).Warningln("failed to init Ethereum client")
 	}
 }
 
+func (ctl *AppController) SuggestAccountSettings() []prompt.Suggest {
+	return []prompt.Suggest{
+		{Text: accountSettingNetwork, Description: "Network to use when the account is selected."},
+		{Text: accountSettingGasPrice, Description: "Gas price in wei to use for the account transactions."},
+	}
+}
+
 func (ctl *AppController) ActionAccountsCreate(args interface{}) {
 	acc, err := ethcore.CreateAccount(ctl.keystorePath, args.(*ethcore.AccountCreateArgs))
 	if err != nil {
@@ -1902,33 +4146,279 @@ func (ctl *AppController) ActionAccountsImportPrivKey(args interface{}) {
 	logrus.Infof("Imported private key for %s", addr.Hex())
 }
 
+func (ctl *AppController) ActionAccountsMnemonic(args interface{}) {
+	mnemonic, addr, err := ethcore.CreateMnemonicAccount(ctl.keystorePath, args.(*ethcore.AccountMnemonicArgs))
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to create new account")
+		return
+	}
+
+	if ctl.takeFirstAccountAsDefault() {
+		saveConfig(ctl.configPath, ctl.cfg)
+	}
+
+	logrus.Infof("Created a new account %s at %s", addr.Hex(), hdwallet.AccountPath(0))
+	fmt.Println(color.RedString("Write down the mnemonic and keep it safe, it's the only way to recover the accounts:"))
+	fmt.Println(mnemonic)
+}
+
+func (ctl *AppController) ActionAccountsImportMnemonic(args interface{}) {
+	addresses, err := ethcore.ImportMnemonic(ctl.keystorePath, args.(*ethcore.AccountImportMnemonicArgs))
+	for _, addr := range addresses {
+		logrus.Infof("Imported HD account %s", addr.Hex())
+	}
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to import mnemonic")
+	}
+
+	if len(addresses) > 0 && ctl.takeFirstAccountAsDefault() {
+		saveConfig(ctl.configPath, ctl.cfg)
+	}
+}
+
+func (ctl *AppController) SuggestMnemonicAccounts(mnemonic, bip39Password string) []prompt.Suggest {
+	const suggestedAccounts = 10
+
+	addresses, err := ethcore.DeriveMnemonicAccounts(mnemonic, bip39Password, 0, suggestedAccounts)
+	if err != nil {
+		logrus.WithError(err).Warningln("failed to derive accounts")
+		return nil
+	}
+
+	suggestions := make([]prompt.Suggest, 0, len(addresses))
+	for idx, addr := range addresses {
+		suggestions = append(suggestions, prompt.Suggest{
+			Text:        strconv.Itoa(idx),
+			Description: fmt.Sprintf("%s %s", hdwallet.AccountPath(uint32(idx)), addr.Hex()),
+		})
+	}
+
+	return suggestions
+}
+
+func (ctl *AppController) ActionAccountsPasswd(args interface{}) {
+	passwdArgs := args.(*ethcore.AccountPasswdArgs)
+
+	if addr, err := ctl.resolveAccount(passwdArgs.Address); err == nil {
+		passwdArgs.Address = addr.Hex()
+	}
+
+	addr, err := ethcore.ChangeAccountPassphrase(ctl.keystore, passwdArgs, false)
+	if err == ethcore.ErrWeakScryptParams {
+		logrus.Warningln(err)
+
+		if !confirmAction("Use the weak scrypt params anyway?") {
+			return
+		}
+
+		addr, err = ethcore.ChangeAccountPassphrase(ctl.keystore, passwdArgs, true)
+	}
+
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to change account passphrase")
+		return
+	}
+
+	logrus.Infof("Changed passphrase of %s", addr.Hex())
+
+	// the session holds the old passphrase, which can't sign anymore
+	if _, ok := ctl.session.Password(addr); ok && ctl.session.Lock() {
+		logrus.Infoln("Signing session locked, unlock it with the new passphrase")
+	}
+}
+
+func (ctl *AppController) ActionAccountsExport(args interface{}) {
+	exportArgs := args.(*ethcore.AccountExportArgs)
+
+	if ctl.remoteSigner {
+		logrus.Errorln("export is not supported by the external signer")
+		return
+	}
+
+	if addr, err := ctl.resolveAccount(exportArgs.Address); err == nil {
+		exportArgs.Address = addr.Hex()
+	}
+
+	addr, err := ethcore.ParseAccount(&ethcore.AccountUseArgs{
+		Address: exportArgs.Address,
+	})
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to export account")
+		return
+	}
+
+	if exportArgs.Destination != ethcore.ExportPrivateKey {
+		destPath, err := ethcore.ExportKeyfile(ctl.keystore, exportArgs)
+		ctl.releaseKey(addr, exportArgs.Password)
+		if err != nil {
+			logrus.WithError(err).Errorln("failed to export account")
+			return
+		}
+
+		logrus.Infof("Exported encrypted keyfile into %s", destPath)
+		return
+	}
+
+	pk, ok := ctl.keystore.PrivateKey(addr, exportArgs.Password)
+	if !ok {
+		logrus.Errorln("failed to decrypt keyfile, check the passphrase")
+		return
+	}
+	defer ctl.releaseKey(addr, exportArgs.Password)
+
+	const confirmation = "reveal"
+	fmt.Println(color.RedString("Anyone who sees the private key gets full control over the account funds."))
+	fmt.Printf("Type %q to print the private key: ", confirmation)
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		logrus.WithError(err).Warningln("failed to read input")
+		return
+	} else if strings.TrimSpace(line) != confirmation {
+		logrus.Warningln("Export cancelled.")
+		return
+	}
+
+	fmt.Printf("Private key of %s: %s\n", addr.Hex(), hex.EncodeToString(crypto.FromECDSA(pk)))
+}
+
+// releaseKey drops the decrypted key from the keystore cache, unless the signing session still uses it.
+func (ctl *AppController) releaseKey(account common.Address, password string) {
+	if sessionPassword, ok := ctl.session.Password(account); ok && sessionPassword == password {
+		return
+	}
+
+	ctl.keystore.UnsetKey(account, password)
+}
+
+func (ctl *AppController) ActionAccountsSign(args interface{}) {
+	signArgs := args.(*ethcore.AccountSignArgs)
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	sig, err := ethcore.SignMessage(ctl.keystore, defaultAccount, signArgs)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to sign message")
+		return
+	}
+
+	fmt.Printf("Signer: %s\nSignature: %s\n", ctl.formatAccount(defaultAccount), hexutil.Encode(sig))
+}
+
+func (ctl *AppController) ActionAccountsVerify(args interface{}) {
+	verifyArgs := args.(*ethcore.AccountVerifyArgs)
+
+	if len(strings.TrimSpace(verifyArgs.Address)) > 0 {
+		if addr, err := ctl.resolveAccount(verifyArgs.Address); err == nil {
+			verifyArgs.Address = addr.Hex()
+		}
+	}
+
+	signer, err := ethcore.VerifyMessage(verifyArgs)
+	if err != nil {
+		if signer != (common.Address{}) {
+			logrus.WithField("signer", signer.Hex()).WithError(err).Errorln("signature is not valid")
+			return
+		}
+
+		logrus.WithError(err).Errorln("failed to verify signature")
+		return
+	}
+
+	logrus.Infof("Signature is valid, signed by %s", ctl.formatAccount(signer))
+}
+
+func (ctl *AppController) SuggestMessageKinds() []prompt.Suggest {
+	return []prompt.Suggest{
+		{Text: ethcore.MessageKindPersonal, Description: "personal_sign text message."},
+		{Text: ethcore.MessageKindTypedData, Description: "EIP-712 typed data JSON."},
+		{Text: ethcore.MessageKindOrder, Description: "0x order JSON, signed with the 0x EIP-712 domain."},
+	}
+}
+
 func (ctl *AppController) ActionAccountsList() {
-	allAccounts := ctl.keystore.Accounts()
+	allAccounts := ctl.allAccounts()
 	if len(allAccounts) == 0 {
-		fmt.Printf("No accounts in %s\n", ctl.mustConfigValue("accounts.keystore"))
+		fmt.Printf("No accounts in %s\n", strings.Join(ctl.keystorePaths(), ", "))
 		return
 	}
 
-	for idx, acc := range allAccounts {
-		fmt.Printf("%d) %s\n", idx+1, acc.Hex())
+	// accounts are grouped by source, keystore dirs are listed even if empty
+	sources := ctl.keystore.Paths()
+	groups := make(map[string][]common.Address)
+	for _, acc := range allAccounts {
+		source := ctl.accountSource(acc)
+		if _, ok := groups[source]; !ok && !stringInSlice(source, sources) {
+			sources = append(sources, source)
+		}
+
+		groups[source] = append(groups[source], acc)
+	}
+
+	idx := 0
+	for i, source := range sources {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("%s:\n", source)
+		if len(groups[source]) == 0 {
+			fmt.Println("   no accounts")
+			continue
+		}
+
+		for _, acc := range groups[source] {
+			idx++
+
+			var settings []string
+			for _, key := range []string{accountSettingNetwork, accountSettingGasPrice} {
+				if v, ok := ctl.accountSetting(acc, key); ok {
+					settings = append(settings, fmt.Sprintf("%s=%s", key, v))
+				}
+			}
+
+			if len(settings) > 0 {
+				fmt.Printf("%d) %s [%s]\n", idx, ctl.formatAccount(acc), strings.Join(settings, ", "))
+			} else {
+				fmt.Printf("%d) %s\n", idx, ctl.formatAccount(acc))
+			}
+		}
 	}
 
-	defaultAccount := ctl.mustConfigValue("accounts.default")
+	defaultAccount := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
 
-	fmt.Printf("\nUsing the default account: %s\n", defaultAccount)
+	fmt.Printf("\nUsing the default account: %s\n", ctl.formatAccount(defaultAccount))
 }
 
 func (ctl *AppController) SuggestAccounts() []prompt.Suggest {
-	allAccounts := ctl.keystore.Accounts()
+	allAccounts := ctl.allAccounts()
 	suggestions := make([]prompt.Suggest, len(allAccounts))
 
 	for i, addr := range allAccounts {
+		if label, ok := ctl.accountLabel(addr); ok {
+			suggestions[i].Text = label
+			suggestions[i].Description = addr.Hex()
+			continue
+		}
+
 		suggestions[i].Text = addr.Hex()
 	}
 
 	return suggestions
 }
 
+func (ctl *AppController) SuggestSpenders() []prompt.Suggest {
+	if ctl.ethCore == nil {
+		return ctl.SuggestAccounts()
+	}
+
+	suggestions := []prompt.Suggest{{
+		Text:        ctl.ethCore.ContractAddress(ethcore.EthContractERC20Proxy).Hex(),
+		Description: "ERC20Proxy, used if left empty",
+	}}
+
+	return append(suggestions, ctl.SuggestAccounts()...)
+}
+
 func (ctl *AppController) SuggestTokens() []prompt.Suggest {
 	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFn()
@@ -1962,74 +4452,189 @@ func (ctl *AppController) SuggestMarkets() []prompt.Suggest {
 			continue
 		}
 
-		suggestions = append(suggestions, prompt.Suggest{
-			Text: pair.Name,
-		})
+		suggestions = append(suggestions, prompt.Suggest{
+			Text: pair.Name,
+		})
+	}
+
+	return suggestions
+}
+
+func (ctl *AppController) SuggestDerivativesMarkets() []prompt.Suggest {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+
+	markets, err := ctl.restClient.DerivativeMarkets(ctx)
+	if err != nil {
+		logrus.WithError(err).Warningln("failed to fetch trade pairs")
+		return nil
+	}
+
+	suggestions := make([]prompt.Suggest, 0, len(markets))
+	for _, market := range markets {
+		if !market.Enabled {
+			continue
+		}
+
+		suggestions = append(suggestions, prompt.Suggest{
+			Text: market.Ticker,
+		})
+	}
+
+	return suggestions
+}
+
+func (ctl *AppController) SuggestOrderToFill(pairName string) []prompt.Suggest {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+
+	bids, asks, err := ctl.sraClient.Orderbook(ctx, pairName)
+	if err != nil {
+		logrus.WithError(err).Warningln("failed to fetch orderbook")
+		return nil
+	}
+
+	suggestions := make([]prompt.Suggest, 0, len(bids)+len(asks))
+	for _, ask := range asks {
+		// TODO: ignore own orders
+
+		zxOrder, _ := ro2zo(ask.Order)
+		orderHash, _ := zxOrder.ComputeOrderHash()
+		price, vol := calcOrderPrice(ask.Order, ask.MetaData["remainingTakerAssetAmount"], false)
+		vol = vol.Shift(-18)
+
+		suggestions = append(suggestions, prompt.Suggest{
+			Text:        orderHash.Hex(),
+			Description: fmt.Sprintf("[ASK] %s %s", price.StringFixed(6), vol.StringFixed(6)),
+		})
+	}
+
+	for _, bid := range bids {
+		// TODO: ignore own orders
+
+		zxOrder, _ := ro2zo(bid.Order)
+		orderHash, _ := zxOrder.ComputeOrderHash()
+		price, vol := calcOrderPrice(bid.Order, bid.MetaData["remainingTakerAssetAmount"], true)
+		vol = vol.Shift(-18)
+
+		suggestions = append(suggestions, prompt.Suggest{
+			Text:        orderHash.Hex(),
+			Description: fmt.Sprintf("[BID] %s %s", price.StringFixed(6), vol.StringFixed(6)),
+		})
+	}
+
+	return suggestions
+}
+
+func (ctl *AppController) SuggestDerivativeOrderToFill(marketName string) []prompt.Suggest {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+
+	market, err := ctl.getDerivativesMarket(ctx, marketName)
+	if err != nil {
+		logrus.WithError(err).Warningln("failed to fetch derivatives market")
+		return nil
+	}
+
+	bids, asks, err := ctl.sdaClient.Orderbook(ctx, market.Ticker)
+	if err != nil {
+		logrus.WithError(err).Warningln("failed to fetch orderbook")
+		return nil
+	}
+
+	owner := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+	suggestions := make([]prompt.Suggest, 0, len(bids)+len(asks))
+
+	addSuggestions := func(side string, records []*sdaAPI.DerivativeOrderRecord) {
+		if err := ctl.annotateDerivativeOrders(ctx, records); err != nil {
+			logrus.WithError(err).Warningln("failed to fetch order states")
+			return
+		}
+
+		for _, record := range records {
+			if isDerivativeMakerOf(record.DerivativeOrder, owner) {
+				continue
+			}
+
+			fillable := decimal.RequireFromString(record.MetaData["remainingTakerAssetAmount"])
+			if fillable.IsZero() {
+				continue
+			}
+
+			price := decimal.RequireFromString(record.DerivativeOrder.MakerAssetAmount).Shift(-18)
+			suggestions = append(suggestions, prompt.Suggest{
+				Text: derivativeOrderHash(record.DerivativeOrder).Hex(),
+				Description: fmt.Sprintf("[%s] %s %s/%s contracts",
+					side, price.StringFixed(6), fillable.String(), record.DerivativeOrder.TakerAssetAmount),
+			})
+		}
 	}
 
+	addSuggestions("SHORT", asks)
+	addSuggestions("LONG", bids)
+
 	return suggestions
 }
 
-func (ctl *AppController) SuggestDerivativesMarkets() []prompt.Suggest {
+func (ctl *AppController) SuggestDerivativeOrderToCancel(marketName string) []prompt.Suggest {
 	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFn()
 
-	markets, err := ctl.restClient.DerivativeMarkets(ctx)
+	owner := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
+
+	records, err := ctl.getOwnDerivativeOrders(ctx, marketName, owner)
 	if err != nil {
-		logrus.WithError(err).Warningln("failed to fetch trade pairs")
+		logrus.WithError(err).Warningln("failed to fetch own orders")
 		return nil
 	}
 
-	suggestions := make([]prompt.Suggest, 0, len(markets))
-	for _, market := range markets {
-		if !market.Enabled {
-			continue
+	suggestions := make([]prompt.Suggest, 0, len(records))
+	for _, record := range records {
+		side := "SHORT"
+		if isLongDerivativeOrder(record.DerivativeOrder) {
+			side = "LONG"
 		}
 
+		price := decimal.RequireFromString(record.DerivativeOrder.MakerAssetAmount).Shift(-18)
 		suggestions = append(suggestions, prompt.Suggest{
-			Text: market.Ticker,
+			Text: derivativeOrderHash(record.DerivativeOrder).Hex(),
+			Description: fmt.Sprintf("[%s] %s %s/%s contracts",
+				side, price.StringFixed(6), record.MetaData["remainingTakerAssetAmount"], record.DerivativeOrder.TakerAssetAmount),
 		})
 	}
 
 	return suggestions
 }
 
-func (ctl *AppController) SuggestOrderToFill(pairName string) []prompt.Suggest {
+func (ctl *AppController) SuggestPositionToClose(marketName string) []prompt.Suggest {
 	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFn()
 
-	bids, asks, err := ctl.sraClient.Orderbook(ctx, pairName)
+	market, err := ctl.getDerivativesMarket(ctx, marketName)
 	if err != nil {
-		logrus.WithError(err).Warningln("failed to fetch orderbook")
+		logrus.WithError(err).Warningln("failed to find derivatives market")
 		return nil
 	}
 
-	suggestions := make([]prompt.Suggest, 0, len(bids)+len(asks))
-	for _, ask := range asks {
-		// TODO: ignore own orders
-
-		zxOrder, _ := ro2zo(ask.Order)
-		orderHash, _ := zxOrder.ComputeOrderHash()
-		price, vol := calcOrderPrice(ask.Order, ask.MetaData["remainingTakerAssetAmount"], false)
-		vol = vol.Shift(-18)
+	owner := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
 
-		suggestions = append(suggestions, prompt.Suggest{
-			Text:        orderHash.Hex(),
-			Description: fmt.Sprintf("[ASK] %s %s", price.StringFixed(6), vol.StringFixed(6)),
-		})
+	positions, err := ctl.ethCore.GetPositionsForTrader(ctx, owner, common.HexToHash(market.MarketID))
+	if err != nil {
+		logrus.WithError(err).Warningln("failed to fetch positions")
+		return nil
 	}
 
-	for _, bid := range bids {
-		// TODO: ignore own orders
-
-		zxOrder, _ := ro2zo(bid.Order)
-		orderHash, _ := zxOrder.ComputeOrderHash()
-		price, vol := calcOrderPrice(bid.Order, bid.MetaData["remainingTakerAssetAmount"], true)
-		vol = vol.Shift(-18)
+	suggestions := make([]prompt.Suggest, 0, len(positions))
+	for _, position := range positions {
+		side := "SHORT"
+		if position.IsLong() {
+			side = "LONG"
+		}
 
 		suggestions = append(suggestions, prompt.Suggest{
-			Text:        orderHash.Hex(),
-			Description: fmt.Sprintf("[BID] %s %s", price.StringFixed(6), vol.StringFixed(6)),
+			Text: position.ID.String(),
+			Description: fmt.Sprintf("[%s] %s contracts at %s, optional if it's the only position",
+				side, position.Quantity.String(), decimal.NewFromBigInt(position.ContractPrice, -18).StringFixed(6)),
 		})
 	}
 
@@ -2094,6 +4699,228 @@ func getAskCurrency(market string) string {
 	return parts[1]
 }
 
+const keystoreWatchInterval = 2 * time.Second
+
+// watchKeystore announces accounts added or removed by other tools while running.
+func (ctl *AppController) watchKeystore(ks keystore.EthKeyStore) {
+	watcher, ok := ks.(keystore.WalletWatcher)
+	if !ok {
+		return
+	}
+
+	eventsC := make(chan *keystore.WalletEvent, 16)
+	watcher.NewWalletSubscribeNotify(eventsC)
+	closer.Bind(watcher.Watch(keystoreWatchInterval))
+
+	go func() {
+		for ev := range eventsC {
+			account := ev.Wallet.HexToAddress()
+
+			switch ev.Kind {
+			case keystore.WalletAdded:
+				// the default account is picked by the prompt goroutine, see applyKeystoreUpdates
+				select {
+				case ctl.walletsAddedC <- struct{}{}:
+				default:
+				}
+
+				logrus.Infof("Account %s appeared in keystore", ctl.formatAccount(account))
+			case keystore.WalletRemoved:
+				logrus.Warningf("Account %s disappeared from keystore", ctl.formatAccount(account))
+
+				if defaultAccount, _ := ctl.getConfigValue("accounts.default"); common.HexToAddress(defaultAccount) == account {
+					logrus.Warningln("The default account keyfile is missing, select another one with use")
+				}
+			}
+		}
+	}()
+}
+
+// applyKeystoreUpdates takes the first account as default once the watcher finds one,
+// so the config is only updated and saved from the prompt goroutine.
+func (ctl *AppController) applyKeystoreUpdates() {
+	select {
+	case <-ctl.walletsAddedC:
+		if ctl.takeFirstAccountAsDefault() {
+			saveConfig(ctl.configPath, ctl.cfg)
+		}
+	default:
+	}
+}
+
+// keystorePaths returns the primary keystore path, where new accounts are created,
+// followed by extra paths from accounts.keystores.
+func (ctl *AppController) keystorePaths() []string {
+	primaryPath, _ := absKeystorePath(ctl.mustConfigValue("accounts.keystore"))
+	paths := []string{primaryPath}
+
+	for _, path := range ctl.extraKeystorePaths() {
+		if !stringInSlice(path, paths) {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+func (ctl *AppController) extraKeystorePaths() []string {
+	var values []string
+	switch v := ctl.rawConfigValue("accounts.keystores").(type) {
+	case []string:
+		values = v
+	case []interface{}:
+		for _, value := range v {
+			if path, ok := value.(string); ok {
+				values = append(values, path)
+			}
+		}
+	case string:
+		values = []string{v}
+	}
+
+	var paths []string
+	for _, path := range values {
+		if len(path) == 0 {
+			continue
+		}
+
+		path, _ = absKeystorePath(path)
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// absKeystorePath expands home dir and makes the path absolute, so the same keystore
+// is matched regardless of how it has been specified. On failure the path is only cleaned.
+func absKeystorePath(path string) (string, error) {
+	path, err := homedir.Expand(strings.TrimSpace(path))
+	if err != nil {
+		return filepath.Clean(path), err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path), err
+	}
+
+	return absPath, nil
+}
+
+// openKeystore opens the keystore with all configured paths, extra paths that can't be used are skipped.
+func (ctl *AppController) openKeystore() (keystore.EthKeyStore, error) {
+	paths := ctl.keystorePaths()
+
+	ks, err := keystore.New(paths[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths[1:] {
+		if err := ks.AddPath(path); err != nil {
+			logrus.WithField("path", path).WithError(err).Warningln("skipping keystore path")
+		}
+	}
+
+	return ks, nil
+}
+
+// accountSource tells where the account comes from: a keystore dir, an external signer or watch-only config.
+func (ctl *AppController) accountSource(account common.Address) string {
+	if ctl.isWatchOnly(account) {
+		return "Watch-only"
+	} else if spec, ok := ctl.keystore.Wallet(account); ok {
+		return filepath.Dir(spec.Path)
+	}
+
+	return "External signer"
+}
+
+type AccountPathArgs struct {
+	Path string
+}
+
+func (ctl *AppController) ActionAccountsAddPath(args interface{}) {
+	path, err := absKeystorePath(args.(*AccountPathArgs).Path)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to add keystore path")
+		return
+	}
+
+	if stringInSlice(path, ctl.keystorePaths()) {
+		logrus.WithField("path", path).Errorln("keystore path is already in use")
+		return
+	}
+
+	if err := ctl.keystore.AddPath(path); err != nil {
+		logrus.WithError(err).Errorln("failed to add keystore path")
+		return
+	}
+
+	ctl.setConfigValue("accounts.keystores", append(ctl.extraKeystorePaths(), path))
+
+	if err := saveConfig(ctl.configPath, ctl.cfg); err != nil {
+		logrus.WithError(err).Errorln("failed to save config file")
+	}
+
+	logrus.Infof("Added keystore path %s", path)
+}
+
+func (ctl *AppController) ActionAccountsRemovePath(args interface{}) {
+	path, err := absKeystorePath(args.(*AccountPathArgs).Path)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to remove keystore path")
+		return
+	}
+
+	if path == ctl.keystorePath {
+		logrus.WithField("path", path).Errorln("primary keystore path can't be removed, change accounts.keystore instead")
+		return
+	}
+
+	var found bool
+	var extraPaths []string
+	for _, extraPath := range ctl.extraKeystorePaths() {
+		if extraPath == path {
+			found = true
+			continue
+		}
+
+		extraPaths = append(extraPaths, extraPath)
+	}
+
+	if !found {
+		logrus.WithField("path", path).Errorln("keystore path not found in config")
+		return
+	}
+
+	ctl.keystore.RemovePath(path)
+
+	if len(extraPaths) > 0 {
+		ctl.setConfigValue("accounts.keystores", extraPaths)
+	} else if err := ctl.deleteConfigValue("accounts.keystores"); err != nil {
+		logrus.WithError(err).Errorln("failed to update config")
+		return
+	}
+
+	if err := saveConfig(ctl.configPath, ctl.cfg); err != nil {
+		logrus.WithError(err).Errorln("failed to save config file")
+	}
+
+	logrus.Infof("Removed keystore path %s, keyfiles are left in place", path)
+}
+
+func (ctl *AppController) SuggestKeystorePaths() []prompt.Suggest {
+	paths := ctl.extraKeystorePaths()
+	suggestions := make([]prompt.Suggest, len(paths))
+
+	for i, path := range paths {
+		suggestions[i].Text = path
+	}
+
+	return suggestions
+}
+
 func (ctl *AppController) takeFirstAccountAsDefault() bool {
 	_, ok := ctl.getConfigValue("accounts.default")
 	if !ok {
@@ -2111,15 +4938,149 @@ func (ctl *AppController) takeFirstAccountAsDefault() bool {
 	return false
 }
 
-func (ctl *AppController) generateDefaultAccount() {
+const (
+	accountSettingLabel     = "label"
+	accountSettingNetwork   = "network"
+	accountSettingGasPrice  = "gas_price"
+	accountSettingWatchOnly = "watch_only"
+)
+
+func accountSettingPath(account common.Address, key string) string {
+	return fmt.Sprintf("accounts.settings.%s.%s", account.Hex(), key)
+}
+
+// accountSetting returns a per-account setting stored under [accounts.settings.<address>].
+func (ctl *AppController) accountSetting(account common.Address, key string) (string, bool) {
+	v, ok := ctl.getConfigValue(accountSettingPath(account, key))
+	if !ok || len(v) == 0 {
+		return "", false
+	}
+
+	return v, true
+}
+
+// setAccountSetting updates a per-account setting and saves config, an empty value removes the setting.
+func (ctl *AppController) setAccountSetting(account common.Address, key, value string) error {
+	path := accountSettingPath(account, key)
+
+	if len(value) > 0 {
+		ctl.setConfigValue(path, value)
+	} else if err := ctl.deleteConfigValue(path); err != nil {
+		return err
+	}
+
+	return saveConfig(ctl.configPath, ctl.cfg)
+}
+
+func (ctl *AppController) accountLabel(account common.Address) (string, bool) {
+	return ctl.accountSetting(account, accountSettingLabel)
+}
+
+func (ctl *AppController) accountByLabel(label string) (common.Address, bool) {
+	for _, key := range ctl.configKeys("accounts.settings") {
+		if !common.IsHexAddress(key) {
+			continue
+		}
+
+		account := common.HexToAddress(key)
+		if accountLabel, ok := ctl.accountLabel(account); ok && accountLabel == label {
+			return account, true
+		}
+	}
+
+	return common.Address{}, false
+}
+
+// resolveAccount parses an account address or finds an account by its label.
+func (ctl *AppController) resolveAccount(addressOrLabel string) (common.Address, error) {
+	addressOrLabel = strings.TrimSpace(addressOrLabel)
+
+	if account, ok := ctl.accountByLabel(addressOrLabel); ok {
+		return account, nil
+	}
+
+	return ethcore.ParseAccount(&ethcore.AccountUseArgs{
+		Address: addressOrLabel,
+	})
+}
+
+// formatAccount renders the account address along with its label, if any.
+func (ctl *AppController) formatAccount(account common.Address) string {
+	if label, ok := ctl.accountLabel(account); ok {
+		return fmt.Sprintf("%s (%s)", label, account.Hex())
+	}
+
+	return account.Hex()
+}
+
+// ownerMark is used to mark orders of the account in orderbooks.
+func (ctl *AppController) ownerMark(account common.Address) string {
+	if label, ok := ctl.accountLabel(account); ok {
+		return label
+	}
+
+	return "owner"
+}
+
+// DefaultAccountLabel returns the label of the default account, if set.
+func (ctl *AppController) DefaultAccountLabel() string {
+	defaultAccount, ok := ctl.getConfigValue("accounts.default")
+	if !ok {
+		return ""
+	}
+
+	label, _ := ctl.accountLabel(common.HexToAddress(defaultAccount))
+	return label
+}
+
+// activeNetwork returns the network preferred by the default account, or the default network.
+func (ctl *AppController) activeNetwork() string {
+	if defaultAccount, ok := ctl.getConfigValue("accounts.default"); ok {
+		if network, ok := ctl.accountSetting(common.HexToAddress(defaultAccount), accountSettingNetwork); ok {
+			return network
+		}
+	}
+
+	return ctl.mustConfigValue("networks.default")
+}
+
+// activeGasPrice returns the gas price preferred by the default account, or the network gas price.
+func (ctl *AppController) activeGasPrice() *big.Int {
+	gasPrice, _ := ctl.getConfigValue(fmt.Sprintf("networks.%s.gas_price", ctl.activeNetwork()), "")
+	if defaultAccount, ok := ctl.getConfigValue("accounts.default"); ok {
+		if v, ok := ctl.accountSetting(common.HexToAddress(defaultAccount), accountSettingGasPrice); ok {
+			gasPrice = v
+		}
+	}
+
+	ethGasPrice, ok := big.NewInt(0).SetString(gasPrice, 10)
+	if !ok {
+		return nil
+	}
+
+	return ethGasPrice
+}
+
+// devNetworks are the only networks where an insecure dev account may be generated.
+var devNetworks = []string{"devnet", "injective", "kovan", "ropsten"}
+
+// generateDefaultAccount creates an account with a well-known passphrase, it's allowed
+// only with --insecure-dev-account and only on dev or test networks.
+func (ctl *AppController) generateDefaultAccount() error {
+	if network, _ := ctl.getConfigValue("networks.default"); !stringInSlice(network, devNetworks) {
+		err := errors.Errorf("insecure dev account can't be generated on %q network, select one of: %s",
+			network, strings.Join(devNetworks, ", "))
+		return err
+	}
+
 	const defaultPassword = "12345678"
 	acc, err := ethcore.CreateAccount(ctl.keystorePath, &ethcore.AccountCreateArgs{
 		Password:       defaultPassword,
 		PasswordRepeat: defaultPassword,
 	})
 	if err != nil {
-		logrus.WithError(err).Errorln("failed to generate default account")
-		return
+		err = errors.Wrap(err, "failed to generate default account")
+		return err
 	}
 
 	ctl.setConfigValue("accounts.default", acc.Address.Hex())
@@ -2127,9 +5088,11 @@ func (ctl *AppController) generateDefaultAccount() {
 	logrus.WithFields(logrus.Fields{
 		"account":    acc.Address.Hex(),
 		"passphrase": defaultPassword,
-	}).Infoln("Created a new default account, encrypting with insecure password.")
+	}).Warningln("Created an insecure dev account with a well-known passphrase, never send real funds to it.")
 
 	logrus.Infoln("To import, create or switch your own accounts use keystore menu.")
+
+	return nil
 }
 
 func (ctl *AppController) selectDefaultNetwork() bool {
@@ -2179,6 +5142,19 @@ func (ctl *AppController) checkTx(txHash common.Hash) {
 	<-spinDone
 }
 
+// awaitTxWithSpin waits for the tx to be mined successfully, showing a spinner meanwhile.
+func (ctl *AppController) awaitTxWithSpin(txHash common.Hash, label string) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Minute)
+	spinDone := makeSpin(ctx, label)
+
+	err := ctl.awaitTx(ctx, txHash)
+
+	cancelFn()
+	<-spinDone
+
+	return err
+}
+
 func (ctl *AppController) awaitTx(ctx context.Context, txHash common.Hash) error {
 	tx, err := ctl.ethCore.Ethereum().TransactionByHash(ctx, txHash.Hex())
 	if err != nil {
@@ -2224,7 +5200,7 @@ func (ctl *AppController) awaitTx(ctx context.Context, txHash common.Hash) error
 }
 
 func (ctl *AppController) formatTxLink(txHash common.Hash) string {
-	networkName := ctl.mustConfigValue("networks.default")
+	networkName := ctl.activeNetwork()
 	explorerEndpoint, ok := ctl.getConfigValue(fmt.Sprintf("networks.%s.explorer", networkName))
 	if ok && len(explorerEndpoint) > 0 {
 		return explorerEndpoint + txHash.Hex()
@@ -2234,10 +5210,9 @@ func (ctl *AppController) formatTxLink(txHash common.Hash) string {
 }
 
 func (ctl *AppController) initEthClient() error {
-	networkName := ctl.mustConfigValue("networks.default")
+	networkName := ctl.activeNetwork()
 
 	ethEndpoint := ctl.mustConfigValue(fmt.Sprintf("networks.%s.endpoint", networkName))
-	ethGasPrice := ctl.mustConfigValue(fmt.Sprintf("networks.%s.gas_price", networkName))
 
 	weth9AddressHex := ctl.mustConfigValue(fmt.Sprintf("networks.%s.weth9_address", networkName))
 	erc20ProxyAddressHex := ctl.mustConfigValue(fmt.Sprintf("networks.%s.erc20proxy_address", networkName))
@@ -2261,11 +5236,7 @@ func (ctl *AppController) initEthClient() error {
 	defaultFromAddress := common.HexToAddress(ctl.mustConfigValue("accounts.default"))
 	allowGasOracles, _ := ctl.getConfigValue("networks.allow_gas_oracles", "true")
 
-	var gasPriceParsed bool
-	ctl.ethGasPrice, gasPriceParsed = big.NewInt(0).SetString(ethGasPrice, 10)
-	if !gasPriceParsed {
-		ctl.ethGasPrice = nil
-	}
+	ctl.ethGasPrice = ctl.activeGasPrice()
 
 	ethCore, err := ethcore.New(
 		ctl.keystore,
@@ -2288,6 +5259,9 @@ func (ctl *AppController) initEthClient() error {
 }
 
 func (ctl *AppController) setConfigValue(path string, v interface{}) {
+	configMux.Lock()
+	defer configMux.Unlock()
+
 	if ctl.cfg == nil {
 		ctl.cfg, _ = toml.TreeFromMap(map[string]interface{}{})
 	}
@@ -2295,6 +5269,38 @@ func (ctl *AppController) setConfigValue(path string, v interface{}) {
 	ctl.cfg.Set(path, v)
 }
 
+func (ctl *AppController) deleteConfigValue(path string) error {
+	configMux.Lock()
+	defer configMux.Unlock()
+
+	if !ctl.cfg.Has(path) {
+		return nil
+	}
+
+	return ctl.cfg.Delete(path)
+}
+
+// rawConfigValue returns the config value as is, for values that aren't strings.
+func (ctl *AppController) rawConfigValue(path string) interface{} {
+	configMux.RLock()
+	defer configMux.RUnlock()
+
+	return ctl.cfg.Get(path)
+}
+
+// configKeys lists keys of the config section.
+func (ctl *AppController) configKeys(path string) []string {
+	configMux.RLock()
+	defer configMux.RUnlock()
+
+	tree, ok := ctl.cfg.Get(path).(*toml.Tree)
+	if !ok {
+		return nil
+	}
+
+	return tree.Keys()
+}
+
 func (ctl *AppController) mustConfigValue(path string) string {
 	val, ok := ctl.getConfigValue(path)
 	if !ok {
@@ -2305,6 +5311,9 @@ func (ctl *AppController) mustConfigValue(path string) string {
 }
 
 func (ctl *AppController) getConfigValue(path string, fallback ...interface{}) (string, bool) {
+	configMux.RLock()
+	defer configMux.RUnlock()
+
 	optVal, optOk := appConfigSetMap[path]
 	if optOk && optVal.SetByUser != nil && *optVal.SetByUser {
 		return *appConfigMap[path], true
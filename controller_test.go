@@ -0,0 +1,283 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+
+	sdaAPI "github.com/InjectiveLabs/dexterm/gen/derivatives_api"
+)
+
+func testDerivativeRecord(maker common.Address, price, quantity, margin, remaining string) *sdaAPI.DerivativeOrderRecord {
+	record := &sdaAPI.DerivativeOrderRecord{
+		DerivativeOrder: &sdaAPI.DerivativeOrder{
+			MakerAddress:          maker.Hex(),
+			MakerAssetAmount:      price,
+			TakerAssetAmount:      quantity,
+			MakerFee:              margin,
+			TakerFee:              "0",
+			ExpirationTimeSeconds: "0",
+			Salt:                  "0",
+		},
+		MetaData: map[string]string{},
+	}
+
+	if len(remaining) > 0 {
+		record.MetaData["remainingTakerAssetAmount"] = remaining
+	}
+
+	return record
+}
+
+func TestAggregateDerivativeLevels(t *testing.T) {
+	owner := common.HexToAddress("0x1")
+	maker := common.HexToAddress("0x2")
+	unknownMaker := common.HexToAddress("0x3")
+
+	const price = "100000000000000000000"
+	const otherPrice = "200000000000000000000"
+
+	testCases := []struct {
+		Name      string
+		Records   []*sdaAPI.DerivativeOrderRecord
+		Available map[common.Address]decimal.Decimal
+
+		Levels    int
+		Quantity  string
+		Orders    int
+		Own       bool
+		Remaining string
+	}{{
+		Name: "orders at the same price are grouped",
+		Records: []*sdaAPI.DerivativeOrderRecord{
+			testDerivativeRecord(owner, price, "10", "100", "10"),
+			testDerivativeRecord(unknownMaker, price, "10", "100", "5"),
+		},
+		Available: map[common.Address]decimal.Decimal{},
+		Levels:    1,
+		Quantity:  "15",
+		Orders:    2,
+		Own:       true,
+	}, {
+		Name: "margin of counted orders is deducted from maker balance",
+		Records: []*sdaAPI.DerivativeOrderRecord{
+			testDerivativeRecord(maker, price, "10", "60", "10"),
+			testDerivativeRecord(maker, otherPrice, "10", "60", "10"),
+		},
+		Available: map[common.Address]decimal.Decimal{
+			maker: decimal.NewFromInt(100),
+		},
+		Levels:    1,
+		Quantity:  "10",
+		Orders:    1,
+		Remaining: "40",
+	}, {
+		Name: "margin is proportional to the remaining quantity",
+		Records: []*sdaAPI.DerivativeOrderRecord{
+			testDerivativeRecord(maker, price, "10", "100", "5"),
+		},
+		Available: map[common.Address]decimal.Decimal{
+			maker: decimal.NewFromInt(50),
+		},
+		Levels:    1,
+		Quantity:  "5",
+		Orders:    1,
+		Remaining: "0",
+	}, {
+		Name: "malformed and filled records are skipped",
+		Records: []*sdaAPI.DerivativeOrderRecord{
+			testDerivativeRecord(unknownMaker, price, "10", "100", ""),
+			testDerivativeRecord(unknownMaker, price, "0", "100", "10"),
+			testDerivativeRecord(unknownMaker, "", "10", "100", "10"),
+			testDerivativeRecord(unknownMaker, price, "10", "100", "0"),
+		},
+		Available: map[common.Address]decimal.Decimal{},
+		Levels:    0,
+	}}
+
+	for _, tc := range testCases {
+		levels := aggregateDerivativeLevels(tc.Records, owner, tc.Available)
+		if len(levels) != tc.Levels {
+			t.Errorf("%s: expected %d levels, got %d", tc.Name, tc.Levels, len(levels))
+			continue
+		} else if tc.Levels == 0 {
+			continue
+		}
+
+		level := levels[0]
+		if !level.Price.Equal(decimal.NewFromInt(100)) {
+			t.Errorf("%s: expected price 100, got %s", tc.Name, level.Price)
+		}
+		if level.Quantity.String() != tc.Quantity {
+			t.Errorf("%s: expected quantity %s, got %s", tc.Name, tc.Quantity, level.Quantity)
+		}
+		if level.Orders != tc.Orders {
+			t.Errorf("%s: expected %d orders, got %d", tc.Name, tc.Orders, level.Orders)
+		}
+		if level.Own != tc.Own {
+			t.Errorf("%s: expected own flag %v", tc.Name, tc.Own)
+		}
+		if len(tc.Remaining) > 0 && tc.Available[maker].String() != tc.Remaining {
+			t.Errorf("%s: expected %s of maker margin left, got %s", tc.Name, tc.Remaining, tc.Available[maker])
+		}
+	}
+}
+
+func TestParseLeverage(t *testing.T) {
+	testCases := []struct {
+		Value    string
+		Expected string
+		Err      bool
+	}{
+		{"", "1", false},
+		{" 2.5 ", "2.5", false},
+		{"10", "10", false},
+		{"0.5", "", true},
+		{"-3", "", true},
+		{"x", "", true},
+	}
+
+	for _, tc := range testCases {
+		leverage, err := parseLeverage(tc.Value)
+		if tc.Err {
+			if err == nil {
+				t.Errorf("%q: expected error, got %s", tc.Value, leverage)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.Value, err)
+			continue
+		}
+
+		if leverage.String() != tc.Expected {
+			t.Errorf("%q: expected %s, got %s", tc.Value, tc.Expected, leverage)
+		}
+	}
+}
+
+func TestLeverageMargin(t *testing.T) {
+	testCases := []struct {
+		Notional  int64
+		Leverage  int64
+		MinMargin int64
+		Expected  string
+		Err       bool
+	}{
+		{1000, 1, 100, "1000", false},
+		{1000, 4, 100, "250", false},
+		{1000, 10, 100, "100", false},
+		{1000, 20, 100, "", true},
+	}
+
+	for _, tc := range testCases {
+		margin, err := leverageMargin(
+			decimal.NewFromInt(tc.Notional),
+			decimal.NewFromInt(tc.Leverage),
+			decimal.NewFromInt(tc.MinMargin),
+		)
+		if tc.Err {
+			if err == nil {
+				t.Errorf("%dx of %d: expected error, got margin %s", tc.Leverage, tc.Notional, margin)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%dx of %d: unexpected error: %v", tc.Leverage, tc.Notional, err)
+			continue
+		}
+
+		if margin.String() != tc.Expected {
+			t.Errorf("%dx of %d: expected margin %s, got %s", tc.Leverage, tc.Notional, tc.Expected, margin)
+		}
+	}
+}
+
+func TestCalcLiquidationPrice(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		IsLong    bool
+		Price     int64
+		Quantity  int64
+		Margin    int64
+		MinMargin int64
+		Expected  string
+	}{
+		{"long", true, 100, 10, 500, 100, "60"},
+		{"short", false, 100, 10, 500, 100, "140"},
+		{"long at min margin", true, 100, 10, 100, 100, "100"},
+		{"long is never negative", true, 100, 1, 500, 100, "0"},
+	}
+
+	for _, tc := range testCases {
+		price := calcLiquidationPrice(
+			tc.IsLong,
+			decimal.NewFromInt(tc.Price),
+			decimal.NewFromInt(tc.Quantity),
+			decimal.NewFromInt(tc.Margin),
+			decimal.NewFromInt(tc.MinMargin),
+		)
+
+		if price.String() != tc.Expected {
+			t.Errorf("%s: expected %s, got %s", tc.Name, tc.Expected, price)
+		}
+	}
+}
+
+func TestParseTokenAmount(t *testing.T) {
+	testCases := []struct {
+		Amount   string
+		Decimals uint8
+		Expected string
+		Err      bool
+	}{
+		{"1", 18, "1000000000000000000", false},
+		{" 0.5 ", 18, "500000000000000000", false},
+		{"1.23", 6, "1230000", false},
+		{"0.000001", 6, "1", false},
+		{"7", 0, "7", false},
+		{"0.0000001", 6, "", true},
+		{"1.5", 0, "", true},
+		{"0", 18, "", true},
+		{"-1", 18, "", true},
+		{"abc", 18, "", true},
+	}
+
+	for _, tc := range testCases {
+		amount, err := parseTokenAmount(tc.Amount, tc.Decimals)
+		if tc.Err {
+			if err == nil {
+				t.Errorf("%q with %d decimals: expected error, got %s", tc.Amount, tc.Decimals, amount)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%q with %d decimals: unexpected error: %v", tc.Amount, tc.Decimals, err)
+			continue
+		}
+
+		if amount.String() != tc.Expected {
+			t.Errorf("%q with %d decimals: expected %s, got %s", tc.Amount, tc.Decimals, tc.Expected, amount)
+		}
+	}
+}
+
+func TestFormatTokenAmount(t *testing.T) {
+	testCases := []struct {
+		Amount   string
+		Decimals uint8
+		Expected string
+	}{
+		{"1000000000000000000", 18, "1"},
+		{"1230000", 6, "1.23"},
+		{"1", 6, "0.000001"},
+		{"7", 0, "7"},
+		{"0", 18, "0"},
+	}
+
+	for _, tc := range testCases {
+		amount, _ := big.NewInt(0).SetString(tc.Amount, 10)
+		if formatted := formatTokenAmount(amount, tc.Decimals); formatted != tc.Expected {
+			t.Errorf("%s with %d decimals: expected %s, got %s", tc.Amount, tc.Decimals, tc.Expected, formatted)
+		}
+	}
+}
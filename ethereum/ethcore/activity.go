@@ -0,0 +1,345 @@
+package ethcore
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/InjectiveLabs/zeroex-go/wrappers"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type ActivityKind string
+
+const (
+	ActivityFill       ActivityKind = "Fill"
+	ActivityCancel     ActivityKind = "Cancel"
+	ActivityDeposit    ActivityKind = "Deposit"
+	ActivityWithdrawal ActivityKind = "Withdrawal"
+	ActivityTransfer   ActivityKind = "Transfer"
+	ActivityApproval   ActivityKind = "Approval"
+)
+
+// ActivityEvent is an event of the account history, decoded from chain logs.
+type ActivityEvent struct {
+	Kind        ActivityKind
+	BlockNumber uint64
+	TxHash      common.Hash
+	LogIndex    uint
+
+	// Asset and Amount are tokens given away by the account in fills, transfers
+	// and withdrawals; approved in approvals; received in deposits and incoming transfers.
+	Asset  common.Address
+	Amount *big.Int
+
+	// Counterparty is the other side of a fill or transfer, or the spender of an approval.
+	Counterparty common.Address
+
+	// Incoming is set for transfers to the account.
+	Incoming bool
+
+	// OrderHash is set for fills and cancels.
+	OrderHash common.Hash
+
+	// ReceivedAsset and ReceivedAmount are tokens received by the account in a fill,
+	// or the taker asset of a cancelled order.
+	ReceivedAsset  common.Address
+	ReceivedAmount *big.Int
+}
+
+var (
+	exchangeABI, _ = abi.JSON(strings.NewReader(wrappers.ExchangeABI))
+	weth9ABI, _    = abi.JSON(strings.NewReader(wrappers.WETH9ABI))
+)
+
+// Activity reconstructs the account history from chain logs of blocks from fromBlock
+// to toBlock inclusive. Events are sorted from the most recent.
+func (cli *EthClient) Activity(ctx context.Context, account common.Address, fromBlock, toBlock uint64) ([]*ActivityEvent, error) {
+	exchangeAddress := cli.contractAddresses[EthContractExchange]
+	wethAddress := cli.contractAddresses[EthContractWETH9]
+	accountTopic := common.BytesToHash(account.Bytes())
+
+	queries := []struct {
+		ethereum.FilterQuery
+		ChunkSize uint64
+	}{{
+		FilterQuery: ethereum.FilterQuery{
+			Addresses: []common.Address{exchangeAddress},
+			Topics: [][]common.Hash{
+				{exchangeABI.Events["Fill"].ID()},
+				{accountTopic},
+			},
+		},
+		ChunkSize: indexedLogsChunkSize,
+	}, {
+		// takerAddress of a Fill is not indexed, so fills of the account as taker
+		// are found among all fills of the exchange.
+		FilterQuery: ethereum.FilterQuery{
+			Addresses: []common.Address{exchangeAddress},
+			Topics: [][]common.Hash{
+				{exchangeABI.Events["Fill"].ID()},
+			},
+		},
+		ChunkSize: unindexedLogsChunkSize,
+	}, {
+		FilterQuery: ethereum.FilterQuery{
+			Addresses: []common.Address{exchangeAddress},
+			Topics: [][]common.Hash{
+				{exchangeABI.Events["Cancel"].ID()},
+				{accountTopic},
+			},
+		},
+		ChunkSize: indexedLogsChunkSize,
+	}, {
+		FilterQuery: ethereum.FilterQuery{
+			Addresses: []common.Address{wethAddress},
+			Topics: [][]common.Hash{
+				{weth9ABI.Events["Deposit"].ID(), weth9ABI.Events["Withdrawal"].ID()},
+				{accountTopic},
+			},
+		},
+		ChunkSize: indexedLogsChunkSize,
+	}, {
+		FilterQuery: ethereum.FilterQuery{
+			Topics: [][]common.Hash{
+				{erc20ABI.Events["Transfer"].ID(), erc20ABI.Events["Approval"].ID()},
+				{accountTopic},
+			},
+		},
+		ChunkSize: indexedLogsChunkSize,
+	}, {
+		FilterQuery: ethereum.FilterQuery{
+			Topics: [][]common.Hash{
+				{erc20ABI.Events["Transfer"].ID()},
+				nil,
+				{accountTopic},
+			},
+		},
+		ChunkSize: indexedLogsChunkSize,
+	}}
+
+	exchangeFilterer, err := wrappers.NewExchangeFilterer(exchangeAddress, cli.ethManager)
+	if err != nil {
+		err = errors.Wrap(err, "failed to init Exchange contract filterer")
+		return nil, err
+	}
+
+	wethFilterer, err := wrappers.NewWETH9Filterer(wethAddress, cli.ethManager)
+	if err != nil {
+		err = errors.Wrap(err, "failed to init WETH9 contract filterer")
+		return nil, err
+	}
+
+	erc20Filterer, err := wrappers.NewERC20Filterer(common.Address{}, cli.ethManager)
+	if err != nil {
+		err = errors.Wrap(err, "failed to init ERC20 contract filterer")
+		return nil, err
+	}
+
+	// fills of own orders and transfers to self are matched by two queries
+	type logID struct {
+		TxHash common.Hash
+		Index  uint
+	}
+	seen := make(map[logID]struct{})
+
+	var events []*ActivityEvent
+	for _, query := range queries {
+		logs, err := cli.filterLogsInChunks(ctx, query.FilterQuery, fromBlock, toBlock, query.ChunkSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, log := range logs {
+			if log.Removed || len(log.Topics) == 0 {
+				continue
+			} else if _, ok := seen[logID{log.TxHash, log.Index}]; ok {
+				continue
+			}
+			seen[logID{log.TxHash, log.Index}] = struct{}{}
+
+			var ev *ActivityEvent
+			var err error
+
+			switch log.Topics[0] {
+			case exchangeABI.Events["Fill"].ID():
+				ev, err = decodeFill(exchangeFilterer, log, account)
+			case exchangeABI.Events["Cancel"].ID():
+				ev, err = decodeCancel(exchangeFilterer, log)
+			case weth9ABI.Events["Deposit"].ID():
+				ev, err = decodeDeposit(wethFilterer, log)
+			case weth9ABI.Events["Withdrawal"].ID():
+				ev, err = decodeWithdrawal(wethFilterer, log)
+			case erc20ABI.Events["Transfer"].ID():
+				ev, err = decodeTransfer(erc20Filterer, log, account)
+			case erc20ABI.Events["Approval"].ID():
+				ev, err = decodeApproval(erc20Filterer, log)
+			}
+
+			if err != nil {
+				logrus.WithError(err).Debugf("skipping log %s of tx %s", log.Topics[0].Hex(), log.TxHash.Hex())
+				continue
+			} else if ev == nil {
+				continue
+			}
+
+			ev.BlockNumber = log.BlockNumber
+			ev.TxHash = log.TxHash
+			ev.LogIndex = log.Index
+			events = append(events, ev)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].BlockNumber != events[j].BlockNumber {
+			return events[i].BlockNumber > events[j].BlockNumber
+		}
+
+		return events[i].LogIndex > events[j].LogIndex
+	})
+
+	return events, nil
+}
+
+// LatestBlockNumber returns the number of the most recent block.
+func (cli *EthClient) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	header, err := cli.ethManager.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return header.Number.Uint64(), nil
+}
+
+func decodeFill(filterer *wrappers.ExchangeFilterer, log types.Log, account common.Address) (*ActivityEvent, error) {
+	fill, err := filterer.ParseFill(log)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := &ActivityEvent{
+		Kind:      ActivityFill,
+		OrderHash: fill.OrderHash,
+	}
+
+	switch account {
+	case fill.MakerAddress:
+		ev.Counterparty = fill.TakerAddress
+		ev.Asset = erc20AssetAddress(fill.MakerAssetData)
+		ev.Amount = fill.MakerAssetFilledAmount
+		ev.ReceivedAsset = erc20AssetAddress(fill.TakerAssetData)
+		ev.ReceivedAmount = fill.TakerAssetFilledAmount
+	case fill.TakerAddress:
+		ev.Counterparty = fill.MakerAddress
+		ev.Asset = erc20AssetAddress(fill.TakerAssetData)
+		ev.Amount = fill.TakerAssetFilledAmount
+		ev.ReceivedAsset = erc20AssetAddress(fill.MakerAssetData)
+		ev.ReceivedAmount = fill.MakerAssetFilledAmount
+	default:
+		// a fill of someone else
+		return nil, nil
+	}
+
+	return ev, nil
+}
+
+func decodeCancel(filterer *wrappers.ExchangeFilterer, log types.Log) (*ActivityEvent, error) {
+	cancel, err := filterer.ParseCancel(log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActivityEvent{
+		Kind:          ActivityCancel,
+		OrderHash:     cancel.OrderHash,
+		Asset:         erc20AssetAddress(cancel.MakerAssetData),
+		ReceivedAsset: erc20AssetAddress(cancel.TakerAssetData),
+	}, nil
+}
+
+func decodeDeposit(filterer *wrappers.WETH9Filterer, log types.Log) (*ActivityEvent, error) {
+	deposit, err := filterer.ParseDeposit(log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActivityEvent{
+		Kind:   ActivityDeposit,
+		Asset:  log.Address,
+		Amount: deposit.Value,
+	}, nil
+}
+
+func decodeWithdrawal(filterer *wrappers.WETH9Filterer, log types.Log) (*ActivityEvent, error) {
+	withdrawal, err := filterer.ParseWithdrawal(log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActivityEvent{
+		Kind:   ActivityWithdrawal,
+		Asset:  log.Address,
+		Amount: withdrawal.Value,
+	}, nil
+}
+
+func decodeTransfer(filterer *wrappers.ERC20Filterer, log types.Log, account common.Address) (*ActivityEvent, error) {
+	// ERC721 Transfer has tokenId indexed as well
+	if len(log.Topics) != 3 {
+		return nil, nil
+	}
+
+	transfer, err := filterer.ParseTransfer(log)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := &ActivityEvent{
+		Kind:         ActivityTransfer,
+		Asset:        log.Address,
+		Amount:       transfer.Value,
+		Counterparty: transfer.To,
+	}
+
+	if transfer.To == account {
+		ev.Incoming = true
+		ev.Counterparty = transfer.From
+	}
+
+	return ev, nil
+}
+
+func decodeApproval(filterer *wrappers.ERC20Filterer, log types.Log) (*ActivityEvent, error) {
+	// ERC721 Approval has tokenId indexed as well
+	if len(log.Topics) != 3 {
+		return nil, nil
+	}
+
+	approval, err := filterer.ParseApproval(log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActivityEvent{
+		Kind:         ActivityApproval,
+		Asset:        log.Address,
+		Amount:       approval.Value,
+		Counterparty: approval.Spender,
+	}, nil
+}
+
+// erc20AssetAddress decodes the token address from ERC20 asset data,
+// other kinds of asset data decode into a zero address.
+func erc20AssetAddress(assetData []byte) common.Address {
+	if len(assetData) != 36 {
+		return common.Address{}
+	}
+
+	return common.BytesToAddress(assetData[4:])
+}
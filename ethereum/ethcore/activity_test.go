@@ -0,0 +1,267 @@
+package ethcore
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/InjectiveLabs/zeroex-go/wrappers"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	testAccount = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	testOther   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	testToken   = common.HexToAddress("0x3333333333333333333333333333333333333333")
+	testWETH    = common.HexToAddress("0x4444444444444444444444444444444444444444")
+	testOrder   = common.HexToHash("0x5555555555555555555555555555555555555555555555555555555555555555")
+)
+
+func testERC20AssetData(token common.Address) []byte {
+	return append(common.FromHex("0xf47261b0"), common.LeftPadBytes(token.Bytes(), 32)...)
+}
+
+// testEventLog encodes an event log emitted by the contract, topics are the indexed args.
+func testEventLog(t *testing.T, contractABI abi.ABI, contract common.Address, name string, topics []common.Hash, args ...interface{}) types.Log {
+	event, ok := contractABI.Events[name]
+	if !ok {
+		t.Fatalf("no %s event in ABI", name)
+	}
+
+	data, err := event.Inputs.NonIndexed().Pack(args...)
+	if err != nil {
+		t.Fatalf("failed to pack %s event: %v", name, err)
+	}
+
+	return types.Log{
+		Address: contract,
+		Topics:  append([]common.Hash{event.ID()}, topics...),
+		Data:    data,
+	}
+}
+
+func TestErc20AssetAddress(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		AssetData []byte
+		Expected  common.Address
+	}{
+		{"ERC20 asset", testERC20AssetData(testToken), testToken},
+		{"empty asset", nil, common.Address{}},
+		{"ERC721 asset", append(testERC20AssetData(testToken), make([]byte, 32)...), common.Address{}},
+	}
+
+	for _, tc := range testCases {
+		if addr := erc20AssetAddress(tc.AssetData); addr != tc.Expected {
+			t.Errorf("%s: expected %s, got %s", tc.Name, tc.Expected.Hex(), addr.Hex())
+		}
+	}
+}
+
+func TestDecodeFill(t *testing.T) {
+	filterer, err := wrappers.NewExchangeFilterer(common.Address{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fillLog := testEventLog(t, exchangeABI, common.Address{}, "Fill",
+		[]common.Hash{testAccount.Hash(), common.Hash{}, testOrder},
+		testERC20AssetData(testToken), testERC20AssetData(testWETH), []byte{}, []byte{},
+		testOther, testOther,
+		big.NewInt(100), big.NewInt(5), big.NewInt(0), big.NewInt(0), big.NewInt(0),
+	)
+
+	testCases := []struct {
+		Name     string
+		Account  common.Address
+		Skipped  bool
+		Asset    common.Address
+		Amount   int64
+		Received common.Address
+		Counter  common.Address
+	}{
+		{"maker", testAccount, false, testToken, 100, testWETH, testOther},
+		{"taker", testOther, false, testWETH, 5, testToken, testAccount},
+		{"someone else", common.HexToAddress("0x6"), true, common.Address{}, 0, common.Address{}, common.Address{}},
+	}
+
+	for _, tc := range testCases {
+		ev, err := decodeFill(filterer, fillLog, tc.Account)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.Name, err)
+			continue
+		} else if tc.Skipped {
+			if ev != nil {
+				t.Errorf("%s: expected the fill to be skipped", tc.Name)
+			}
+			continue
+		} else if ev == nil {
+			t.Errorf("%s: expected a fill event", tc.Name)
+			continue
+		}
+
+		if ev.Kind != ActivityFill || ev.OrderHash != testOrder {
+			t.Errorf("%s: wrong kind %s or order hash %s", tc.Name, ev.Kind, ev.OrderHash.Hex())
+		}
+		if ev.Asset != tc.Asset || ev.Amount.Int64() != tc.Amount {
+			t.Errorf("%s: expected %d of %s given, got %s of %s", tc.Name, tc.Amount, tc.Asset.Hex(), ev.Amount, ev.Asset.Hex())
+		}
+		if ev.ReceivedAsset != tc.Received {
+			t.Errorf("%s: expected %s received, got %s", tc.Name, tc.Received.Hex(), ev.ReceivedAsset.Hex())
+		}
+		if ev.Counterparty != tc.Counter {
+			t.Errorf("%s: expected counterparty %s, got %s", tc.Name, tc.Counter.Hex(), ev.Counterparty.Hex())
+		}
+	}
+}
+
+func TestDecodeCancel(t *testing.T) {
+	filterer, err := wrappers.NewExchangeFilterer(common.Address{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancelLog := testEventLog(t, exchangeABI, common.Address{}, "Cancel",
+		[]common.Hash{testAccount.Hash(), common.Hash{}, testOrder},
+		testERC20AssetData(testToken), testERC20AssetData(testWETH), testAccount,
+	)
+
+	ev, err := decodeCancel(filterer, cancelLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ev.Kind != ActivityCancel || ev.OrderHash != testOrder {
+		t.Errorf("wrong kind %s or order hash %s", ev.Kind, ev.OrderHash.Hex())
+	}
+	if ev.Asset != testToken || ev.ReceivedAsset != testWETH {
+		t.Errorf("expected %s/%s assets, got %s/%s", testToken.Hex(), testWETH.Hex(), ev.Asset.Hex(), ev.ReceivedAsset.Hex())
+	}
+}
+
+func TestDecodeWETH9(t *testing.T) {
+	filterer, err := wrappers.NewWETH9Filterer(testWETH, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Event  string
+		Kind   ActivityKind
+		Decode func(*wrappers.WETH9Filterer, types.Log) (*ActivityEvent, error)
+	}{
+		{"Deposit", ActivityDeposit, decodeDeposit},
+		{"Withdrawal", ActivityWithdrawal, decodeWithdrawal},
+	}
+
+	for _, tc := range testCases {
+		log := testEventLog(t, weth9ABI, testWETH, tc.Event, []common.Hash{testAccount.Hash()}, big.NewInt(42))
+
+		ev, err := tc.Decode(filterer, log)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.Event, err)
+			continue
+		}
+
+		if ev.Kind != tc.Kind || ev.Asset != testWETH || ev.Amount.Int64() != 42 {
+			t.Errorf("%s: expected %s of 42 %s, got %s of %s %s", tc.Event, tc.Kind, testWETH.Hex(), ev.Kind, ev.Amount, ev.Asset.Hex())
+		}
+	}
+}
+
+func TestDecodeERC20(t *testing.T) {
+	erc20ABI, err := abi.JSON(strings.NewReader(wrappers.ERC20ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filterer, err := wrappers.NewERC20Filterer(testToken, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outgoing := testEventLog(t, erc20ABI, testToken, "Transfer",
+		[]common.Hash{testAccount.Hash(), testOther.Hash()}, big.NewInt(7))
+	incoming := testEventLog(t, erc20ABI, testToken, "Transfer",
+		[]common.Hash{testOther.Hash(), testAccount.Hash()}, big.NewInt(7))
+	approval := testEventLog(t, erc20ABI, testToken, "Approval",
+		[]common.Hash{testAccount.Hash(), testOther.Hash()}, big.NewInt(7))
+
+	// ERC721 events share the signatures, but have tokenId indexed
+	nonFungible := incoming
+	nonFungible.Topics = append(nonFungible.Topics[:3:3], common.BigToHash(big.NewInt(7)))
+	nonFungible.Data = nil
+
+	testCases := []struct {
+		Name     string
+		Log      types.Log
+		Decode   func(log types.Log) (*ActivityEvent, error)
+		Skipped  bool
+		Kind     ActivityKind
+		Incoming bool
+	}{{
+		Name: "outgoing transfer",
+		Log:  outgoing,
+		Decode: func(log types.Log) (*ActivityEvent, error) {
+			return decodeTransfer(filterer, log, testAccount)
+		},
+		Kind: ActivityTransfer,
+	}, {
+		Name: "incoming transfer",
+		Log:  incoming,
+		Decode: func(log types.Log) (*ActivityEvent, error) {
+			return decodeTransfer(filterer, log, testAccount)
+		},
+		Kind:     ActivityTransfer,
+		Incoming: true,
+	}, {
+		Name: "approval",
+		Log:  approval,
+		Decode: func(log types.Log) (*ActivityEvent, error) {
+			return decodeApproval(filterer, log)
+		},
+		Kind: ActivityApproval,
+	}, {
+		Name: "ERC721 transfer",
+		Log:  nonFungible,
+		Decode: func(log types.Log) (*ActivityEvent, error) {
+			return decodeTransfer(filterer, log, testAccount)
+		},
+		Skipped: true,
+	}, {
+		Name: "ERC721 approval",
+		Log:  nonFungible,
+		Decode: func(log types.Log) (*ActivityEvent, error) {
+			return decodeApproval(filterer, log)
+		},
+		Skipped: true,
+	}}
+
+	for _, tc := range testCases {
+		ev, err := tc.Decode(tc.Log)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.Name, err)
+			continue
+		} else if tc.Skipped {
+			if ev != nil {
+				t.Errorf("%s: expected the log to be skipped", tc.Name)
+			}
+			continue
+		} else if ev == nil {
+			t.Errorf("%s: expected an event", tc.Name)
+			continue
+		}
+
+		if ev.Kind != tc.Kind || ev.Incoming != tc.Incoming {
+			t.Errorf("%s: expected %s with incoming %v, got %s with %v", tc.Name, tc.Kind, tc.Incoming, ev.Kind, ev.Incoming)
+		}
+		if ev.Asset != testToken || ev.Amount.Int64() != 7 {
+			t.Errorf("%s: expected 7 of %s, got %s of %s", tc.Name, testToken.Hex(), ev.Amount, ev.Asset.Hex())
+		}
+		if ev.Counterparty != testOther {
+			t.Errorf("%s: expected counterparty %s, got %s", tc.Name, testOther.Hex(), ev.Counterparty.Hex())
+		}
+	}
+}
@@ -11,23 +11,29 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	zeroex "github.com/InjectiveLabs/zeroex-go"
 	"github.com/InjectiveLabs/zeroex-go/wrappers"
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethKeystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	signercore "github.com/ethereum/go-ethereum/signer/core"
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/InjectiveLabs/dexterm/ethereum/ethfw"
 	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/gasmeter"
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/hdwallet"
 	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
 	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/manager"
 )
@@ -47,6 +53,9 @@ type EthClient struct {
 	coordinator    *wrappers.Coordinator
 	devUtils    	*wrappers.DevUtils
 	futures        *wrappers.Futures
+
+	oracleDecimals    map[common.Address]uint8
+	oracleDecimalsMux *sync.RWMutex
 }
 
 type EthContract string
@@ -76,6 +85,8 @@ func New(
 		ercWrappersMux:    new(sync.RWMutex),
 		salt:              big.NewInt(time.Now().Unix()),
 		saltMux:           new(sync.Mutex),
+		oracleDecimals:    make(map[common.Address]uint8),
+		oracleDecimalsMux: new(sync.RWMutex),
 	}
 
 	if err := cli.initContractWrappers(); err != nil {
@@ -174,6 +185,14 @@ type CallArgs struct {
 // UnlimitedAllowance is uint constant MAX_UINT = 2**256 - 1
 var UnlimitedAllowance = big.NewInt(0).Sub(big.NewInt(0).Exp(big.NewInt(2), big.NewInt(256), nil), big.NewInt(1))
 
+var unlimitedAllowanceThreshold = big.NewInt(0).Rsh(UnlimitedAllowance, 1)
+
+// IsUnlimitedAllowance checks if allowance exceeds half of MAX_UINT, some tokens decrease
+// an unlimited allowance on each transfer.
+func IsUnlimitedAllowance(allowance *big.Int) bool {
+	return allowance.Cmp(unlimitedAllowanceThreshold) > 0
+}
+
 func (cli *EthClient) Contracts() map[EthContract]common.Address {
 	return cli.contractAddresses
 }
@@ -307,6 +326,120 @@ func (cli *EthClient) Approve(call *CallArgs, asset, spender common.Address, val
 	return txHash, err
 }
 
+// TokenApproval is an allowance of the owner's tokens to a spender.
+type TokenApproval struct {
+	Asset     common.Address
+	Spender   common.Address
+	Allowance *big.Int
+}
+
+// Approvals scans ERC20 Approval events of the owner on the assets in blocks from fromBlock
+// to toBlock and returns approvals having non-zero allowance at the moment. Allowances are
+// not taken from logs, since transferFrom decreases them without emitting an event.
+// If the scan fails midway, approvals found in the recent blocks scanned so far are
+// returned along with the error.
+func (cli *EthClient) Approvals(
+	ctx context.Context,
+	owner common.Address,
+	assets []common.Address,
+	fromBlock, toBlock uint64,
+) ([]*TokenApproval, error) {
+	if len(assets) == 0 {
+		return nil, nil
+	}
+
+	logs, scanErr := cli.filterLogsInChunks(ctx, ethereum.FilterQuery{
+		Addresses: assets,
+		Topics: [][]common.Hash{
+			{erc20ABI.Events["Approval"].ID()},
+			{common.BytesToHash(owner.Bytes())},
+		},
+	}, fromBlock, toBlock, indexedLogsChunkSize)
+	if scanErr != nil {
+		scanErr = errors.Wrap(scanErr, "failed to filter Approval logs")
+	}
+
+	seen := make(map[[2]common.Address]struct{}, len(logs))
+	approvals := make([]*TokenApproval, 0, len(logs))
+
+	for _, approvalLog := range logs {
+		// ERC721 Approval has tokenId indexed as well
+		if approvalLog.Removed || len(approvalLog.Topics) != 3 {
+			continue
+		}
+
+		spender := common.BytesToAddress(approvalLog.Topics[2].Bytes())
+		key := [2]common.Address{approvalLog.Address, spender}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		allowance, err := cli.Allowance(ctx, owner, spender, approvalLog.Address)
+		if err != nil {
+			err = errors.Wrapf(err, "unable to get allowance of %s", approvalLog.Address.Hex())
+			return nil, err
+		} else if allowance.Sign() == 0 {
+			continue
+		}
+
+		approvals = append(approvals, &TokenApproval{
+			Asset:     approvalLog.Address,
+			Spender:   spender,
+			Allowance: allowance,
+		})
+	}
+
+	return approvals, scanErr
+}
+
+// RevokeApprovals sets allowances to zero, txs are sent one by one with sequential nonces.
+// Hashes of the txs sent before an error are returned along with the error.
+func (cli *EthClient) RevokeApprovals(call *CallArgs, approvals []*TokenApproval) (txHashes []common.Hash, err error) {
+	opts := cli.transactOpts(call)
+
+	err = cli.nonceCache.Serialize(opts.From, func() error {
+		for _, approval := range approvals {
+			erc20Wrapper, err := cli.erc20Wrapper(approval.Asset)
+			if err != nil {
+				return err
+			}
+
+			nonce := cli.nonceCache.Incr(opts.From)
+			var resyncUsed bool
+
+			for {
+				opts.Nonce = big.NewInt(nonce)
+
+				var cancelFn context.CancelFunc
+				opts.Context, cancelFn = context.WithTimeout(context.Background(), 30*time.Second)
+
+				tx, err := erc20Wrapper.Approve(opts, approval.Spender, big.NewInt(0))
+				cancelFn()
+
+				if err != nil {
+					resyncUsed, err = cli.handleTxError(err, opts.From, resyncUsed)
+					if err != nil {
+						// unhandled error
+						return err
+					}
+
+					// try again with new nonce
+					nonce = cli.nonceCache.Incr(opts.From)
+					continue
+				}
+
+				txHashes = append(txHashes, tx.Hash())
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return txHashes, err
+}
+
 var (
 	ErrAlreadyLocked   = errors.New("token aleady locked")
 	ErrAlreadyUnlocked = errors.New("token aleady unlocked")
@@ -518,6 +651,226 @@ func (cli *EthClient) EthUnwrap(call *CallArgs, amount *big.Int) (txHash common.
 	return txHash, err
 }
 
+var ErrInsufficientTokenBalance = errors.New("insufficient token balance")
+
+// TransferEstimate is the gas a send or transfer tx is expected to consume.
+type TransferEstimate struct {
+	Gas      uint64
+	GasPrice *big.Int
+}
+
+// Fee returns the max amount of wei paid for gas.
+func (e *TransferEstimate) Fee() *big.Int {
+	return big.NewInt(0).Mul(big.NewInt(0).SetUint64(e.Gas), e.GasPrice)
+}
+
+var erc20ABI, _ = abi.JSON(strings.NewReader(wrappers.ERC20ABI))
+
+const erc20DecimalsABI = `[{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"}]`
+
+var erc20Decimals, _ = abi.JSON(strings.NewReader(erc20DecimalsABI))
+
+// TokenDecimals returns the number of decimals of an ERC20 token. The ERC20 wrapper
+// doesn't have it because the method is optional in the standard.
+func (cli *EthClient) TokenDecimals(ctx context.Context, asset common.Address) (uint8, error) {
+	data, err := erc20Decimals.Pack("decimals")
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := cli.ethManager.CallContract(ctx, ethereum.CallMsg{
+		To:   &asset,
+		Data: data,
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var decimals uint8
+	if err := erc20Decimals.Unpack(&decimals, "decimals", out); err != nil {
+		return 0, errors.Wrap(err, "token has no decimals")
+	}
+
+	return decimals, nil
+}
+
+// EstimateEthSend estimates gas of sending ETH and checks that the balance covers both the amount and the fee.
+func (cli *EthClient) EstimateEthSend(call *CallArgs, to common.Address, amount *big.Int) (*TransferEstimate, error) {
+	opts := cli.transactOpts(call)
+
+	gas, err := cli.ethManager.EstimateGas(opts.Context, ethereum.CallMsg{
+		From:     opts.From,
+		To:       &to,
+		GasPrice: opts.GasPrice,
+		Value:    amount,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "failed to estimate gas")
+		return nil, err
+	}
+
+	estimate := &TransferEstimate{
+		Gas:      gas,
+		GasPrice: opts.GasPrice,
+	}
+
+	balance, err := cli.EthBalance(opts.Context, opts.From)
+	if err != nil {
+		err = errors.Wrap(err, "could not check ETH balance")
+		return nil, err
+	} else if balance.Cmp(big.NewInt(0).Add(amount, estimate.Fee())) < 0 {
+		return nil, ErrInsufficientEthBalance
+	}
+
+	return estimate, nil
+}
+
+// EstimateTokenTransfer estimates gas of an ERC20 transfer and checks that the balances
+// cover the amount of tokens and the fee in ETH.
+func (cli *EthClient) EstimateTokenTransfer(
+	call *CallArgs,
+	asset, to common.Address,
+	amount *big.Int,
+) (*TransferEstimate, error) {
+	opts := cli.transactOpts(call)
+
+	balance, err := cli.BalanceOf(opts.Context, opts.From, asset)
+	if err != nil {
+		err = errors.Wrap(err, "could not check token balance")
+		return nil, err
+	} else if balance.Cmp(amount) < 0 {
+		return nil, ErrInsufficientTokenBalance
+	}
+
+	data, err := erc20ABI.Pack("transfer", to, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	gas, err := cli.ethManager.EstimateGas(opts.Context, ethereum.CallMsg{
+		From:     opts.From,
+		To:       &asset,
+		GasPrice: opts.GasPrice,
+		Data:     data,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "failed to estimate gas")
+		return nil, err
+	}
+
+	estimate := &TransferEstimate{
+		Gas:      gas,
+		GasPrice: opts.GasPrice,
+	}
+
+	ethBalance, err := cli.EthBalance(opts.Context, opts.From)
+	if err != nil {
+		err = errors.Wrap(err, "could not check ETH balance")
+		return nil, err
+	} else if ethBalance.Cmp(estimate.Fee()) < 0 {
+		return nil, ErrInsufficientEthBalance
+	}
+
+	return estimate, nil
+}
+
+func (cli *EthClient) EthSend(
+	call *CallArgs,
+	to common.Address,
+	amount *big.Int,
+	estimate *TransferEstimate,
+) (txHash common.Hash, err error) {
+	opts := cli.transactOpts(call)
+	opts.GasLimit = estimate.Gas
+	opts.GasPrice = estimate.GasPrice
+
+	// a contract without ABI sends plain value transfers
+	recipient := bind.NewBoundContract(to, abi.ABI{}, cli.ethManager, cli.ethManager, cli.ethManager)
+
+	err = cli.nonceCache.Serialize(opts.From, func() error {
+		nonce := cli.nonceCache.Incr(opts.From)
+		var resyncUsed bool
+
+		for {
+			opts.Nonce = big.NewInt(nonce)
+
+			var cancelFn context.CancelFunc
+			opts.Context, cancelFn = context.WithTimeout(context.Background(), 30*time.Second)
+
+			// attach ETH to the transaction
+			opts.Value = amount
+
+			tx, err := recipient.Transfer(opts)
+			cancelFn()
+
+			if err != nil {
+				resyncUsed, err = cli.handleTxError(err, opts.From, resyncUsed)
+				if err != nil {
+					// unhandled error
+					return err
+				}
+
+				// try again with new nonce
+				nonce = cli.nonceCache.Incr(opts.From)
+				continue
+			}
+
+			txHash = tx.Hash()
+			return nil
+		}
+	})
+
+	return txHash, err
+}
+
+func (cli *EthClient) TokenTransfer(
+	call *CallArgs,
+	asset, to common.Address,
+	amount *big.Int,
+	estimate *TransferEstimate,
+) (txHash common.Hash, err error) {
+	opts := cli.transactOpts(call)
+	opts.GasLimit = estimate.Gas
+	opts.GasPrice = estimate.GasPrice
+
+	var erc20Wrapper *wrappers.ERC20
+	if erc20Wrapper, err = cli.erc20Wrapper(asset); err != nil {
+		return
+	}
+
+	err = cli.nonceCache.Serialize(opts.From, func() error {
+		nonce := cli.nonceCache.Incr(opts.From)
+		var resyncUsed bool
+
+		for {
+			opts.Nonce = big.NewInt(nonce)
+
+			var cancelFn context.CancelFunc
+			opts.Context, cancelFn = context.WithTimeout(context.Background(), 30*time.Second)
+
+			tx, err := erc20Wrapper.Transfer(opts, to, amount)
+			cancelFn()
+
+			if err != nil {
+				resyncUsed, err = cli.handleTxError(err, opts.From, resyncUsed)
+				if err != nil {
+					// unhandled error
+					return err
+				}
+
+				// try again with new nonce
+				nonce = cli.nonceCache.Incr(opts.From)
+				continue
+			}
+
+			txHash = tx.Hash()
+			return nil
+		}
+	})
+
+	return txHash, err
+}
+
 func (cli *EthClient) ExecuteTransaction(
 	call *CallArgs,
 	zeroExTx *zeroex.SignedTransaction,
@@ -570,13 +923,9 @@ func (cli *EthClient) ExecuteTransaction(
 }
 
 func (cli *EthClient) SignOrder(call *CallArgs, order *zeroex.Order) (*zeroex.SignedOrder, error) {
-	pk, ok := cli.keystore.PrivateKey(call.From, call.FromPass)
-	if !ok {
-		err := errors.New("privkey not loaded")
-		return nil, err
-	}
+	signer := newKeystoreSigner(cli.keystore, call.FromPass, keystore.HashKindOrder)
 
-	signedOrder, err := zeroex.SignOrder(zeroex.NewLocalSigner(pk), order)
+	signedOrder, err := zeroex.SignOrder(signer, order)
 	if err != nil {
 		err = errors.Wrap(err, "failed to sign order")
 		return nil, err
@@ -585,6 +934,45 @@ func (cli *EthClient) SignOrder(call *CallArgs, order *zeroex.Order) (*zeroex.Si
 	return signedOrder, nil
 }
 
+// keystoreSigner produces eth_sign signatures using the keystore, so the private key
+// may stay outside of the process, e.g. in a key agent.
+type keystoreSigner struct {
+	ks       keystore.EthKeyStore
+	password string
+	kind     keystore.HashKind
+}
+
+func newKeystoreSigner(ks keystore.EthKeyStore, password string, kind keystore.HashKind) zeroex.Signer {
+	return &keystoreSigner{
+		ks:       ks,
+		password: password,
+		kind:     kind,
+	}
+}
+
+func (s *keystoreSigner) EthSign(message []byte, signerAddress common.Address) (*zeroex.ECSignature, error) {
+	if len(message) != common.HashLength {
+		err := errors.Errorf("expected a %d byte hash to sign, got %d bytes", common.HashLength, len(message))
+		return nil, err
+	}
+
+	sig, err := s.ks.SignHash(signerAddress, s.password, s.kind, common.BytesToHash(message))
+	if err != nil {
+		err = errors.Wrap(err, "privkey not loaded")
+		return nil, err
+	}
+
+	return &zeroex.ECSignature{
+		V: sig[64] + 27,
+		R: common.BytesToHash(sig[0:32]),
+		S: common.BytesToHash(sig[32:64]),
+	}, nil
+}
+
+func (s *keystoreSigner) EcRecover(message []byte, sig []byte) (common.Address, error) {
+	return (&zeroex.LocalSigner{}).EcRecover(message, sig)
+}
+
 func (cli *EthClient) CreateAndSignOrder(
 	call *CallArgs,
 	feeRecipientAddress common.Address,
@@ -619,14 +1007,17 @@ func (cli *EthClient) CreateAndSignDerivativesOrder(
 	call *CallArgs,
 	makerAssetData, takerAssetData []byte,
 	makerAssetAmount, takerAssetAmount *big.Int, isLong bool,
+	margin *big.Int,
 ) (*zeroex.SignedOrder, error) {
 
 	//direction := big.NewInt(1)
 	//if !isLong {
 	//	direction = big.NewInt(2)
 	//}
-	// TODO: change from just 1x leverage
-	margin := big.NewInt(0).Mul(makerAssetAmount, takerAssetAmount)
+	if margin == nil || margin.Sign() <= 0 {
+		// fallback to 1x leverage
+		margin = big.NewInt(0).Mul(makerAssetAmount, takerAssetAmount)
+	}
 
 	zeroAssetBytes := common.FromHex("0x000000000000000000000000000000000000000000000000000000000000000000000000")
 	order := &zeroex.Order{
@@ -671,48 +1062,460 @@ func (cli *EthClient) GetOrderRelevantStates(
 	return cli.futures.GetOrderRelevantStates(opts, orders, signatures)
 }
 
-func (cli *EthClient) GetZeroExOrderRelevantStates(
-	ctx context.Context,
-	orders []wrappers.Order,
-	signatures [][]byte,
-) (struct {
-	OrdersInfo                []wrappers.OrderInfo
-	FillableTakerAssetAmounts []*big.Int
-	IsValidSignature          []bool
-}, error) {
+// FillDerivativesOrder takes the given quantity of contracts from a derivatives order,
+// locking the specified margin for the opposite position of the taker.
+func (cli *EthClient) FillDerivativesOrder(
+	call *CallArgs,
+	order wrappers.Order,
+	signature []byte,
+	quantity, margin *big.Int,
+) (txHash common.Hash, err error) {
+	opts := cli.transactOpts(call)
 
-	opts := &bind.CallOpts{
-		Context: ctx,
-		From:    cli.ContractAddress(EthContractDevUtils),
-	}
+	err = cli.nonceCache.Serialize(opts.From, func() error {
+		nonce := cli.nonceCache.Incr(opts.From)
+		var resyncUsed bool
 
-	return cli.devUtils.GetOrderRelevantStates(opts, orders, signatures)
-}
+		for {
+			opts.Nonce = big.NewInt(nonce)
 
-func (cli *EthClient) GetTransferableAssetAmount(
-	ctx context.Context,
-	ownerAddress common.Address,
-) (amount *big.Int, err error) {
+			ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
+			opts.Context = ctx
 
-	opts := &bind.CallOpts{
-		Context: ctx,
-		From:    cli.ContractAddress(EthContractFutures),
-	}
-	return cli.futures.GetTransferableAssetAmount(opts, ownerAddress)
-}
+			tx, err := cli.futures.FillOrder(opts, order, quantity, margin, signature)
+			cancelFn()
+			if err != nil {
+				resyncUsed, err = cli.handleTxError(err, opts.From, resyncUsed)
+				if err != nil {
+					// unhandled error
+					return err
+				}
 
-func (cli *EthClient) chainID() *big.Int {
-	return big.NewInt(int64(cli.ethManager.ChainID()))
+				// try again with new nonce
+				nonce = cli.nonceCache.Incr(opts.From)
+				continue
+			}
+
+			txHash = tx.Hash()
+			return nil
+		}
+	})
+
+	return txHash, err
 }
 
-func (cli *EthClient) SignTransaction(call *CallArgs, tx *zeroex.Transaction) (*zeroex.SignedTransaction, error) {
-	pk, ok := cli.keystore.PrivateKey(call.From, call.FromPass)
-	if !ok {
-		err := errors.New("privkey not loaded")
+// CancelDerivativesOrder cancels a derivatives order on the Futures contract,
+// the transaction must be sent from the maker of the order.
+func (cli *EthClient) CancelDerivativesOrder(call *CallArgs, order wrappers.Order) (txHash common.Hash, err error) {
+	opts := cli.transactOpts(call)
+
+	err = cli.nonceCache.Serialize(opts.From, func() error {
+		nonce := cli.nonceCache.Incr(opts.From)
+		var resyncUsed bool
+
+		for {
+			opts.Nonce = big.NewInt(nonce)
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
+			opts.Context = ctx
+
+			tx, err := cli.futures.CancelOrder(opts, order)
+			cancelFn()
+			if err != nil {
+				resyncUsed, err = cli.handleTxError(err, opts.From, resyncUsed)
+				if err != nil {
+					// unhandled error
+					return err
+				}
+
+				// try again with new nonce
+				nonce = cli.nonceCache.Incr(opts.From)
+				continue
+			}
+
+			txHash = tx.Hash()
+			return nil
+		}
+	})
+
+	return txHash, err
+}
+
+// FuturesDeposit deposits base currency into the Futures contract as a free collateral.
+// The Futures contract must be approved to spend the amount beforehand.
+func (cli *EthClient) FuturesDeposit(call *CallArgs, amount *big.Int) (txHash common.Hash, err error) {
+	opts := cli.transactOpts(call)
+
+	err = cli.nonceCache.Serialize(opts.From, func() error {
+		nonce := cli.nonceCache.Incr(opts.From)
+		var resyncUsed bool
+
+		for {
+			opts.Nonce = big.NewInt(nonce)
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
+			opts.Context = ctx
+
+			tx, err := cli.futures.Deposit(opts, amount)
+			cancelFn()
+			if err != nil {
+				resyncUsed, err = cli.handleTxError(err, opts.From, resyncUsed)
+				if err != nil {
+					// unhandled error
+					return err
+				}
+
+				// try again with new nonce
+				nonce = cli.nonceCache.Incr(opts.From)
+				continue
+			}
+
+			txHash = tx.Hash()
+			return nil
+		}
+	})
+
+	return txHash, err
+}
+
+// FuturesWithdraw withdraws free collateral from the Futures contract back to the account.
+func (cli *EthClient) FuturesWithdraw(call *CallArgs, amount *big.Int) (txHash common.Hash, err error) {
+	opts := cli.transactOpts(call)
+
+	err = cli.nonceCache.Serialize(opts.From, func() error {
+		nonce := cli.nonceCache.Incr(opts.From)
+		var resyncUsed bool
+
+		for {
+			opts.Nonce = big.NewInt(nonce)
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
+			opts.Context = ctx
+
+			tx, err := cli.futures.Withdraw(opts, amount)
+			cancelFn()
+			if err != nil {
+				resyncUsed, err = cli.handleTxError(err, opts.From, resyncUsed)
+				if err != nil {
+					// unhandled error
+					return err
+				}
+
+				// try again with new nonce
+				nonce = cli.nonceCache.Incr(opts.From)
+				continue
+			}
+
+			txHash = tx.Hash()
+			return nil
+		}
+	})
+
+	return txHash, err
+}
+
+// Directions of positions in the Futures contract.
+const (
+	PositionDirectionLong  uint8 = 0
+	PositionDirectionShort uint8 = 1
+)
+
+// Position is an open futures position of a trader.
+type Position struct {
+	wrappers.TypesPosition
+
+	ID *big.Int
+}
+
+// IsLong checks whether the position is a long one.
+func (p *Position) IsLong() bool {
+	return p.Direction == PositionDirectionLong
+}
+
+// GetPositionsForTrader returns non-empty positions of the trader in the market.
+func (cli *EthClient) GetPositionsForTrader(
+	ctx context.Context,
+	trader common.Address,
+	marketID common.Hash,
+) ([]*Position, error) {
+	opts := &bind.CallOpts{
+		Context: ctx,
+		From:    cli.ContractAddress(EthContractFutures),
+	}
+
+	positionIDs, err := cli.futures.GetPositionIDsForTrader(opts, trader, marketID)
+	if err != nil {
+		err = errors.Wrap(err, "failed to get position IDs")
+		return nil, err
+	}
+
+	positions, err := cli.futures.GetPositionsForTrader(opts, trader, marketID)
+	if err != nil {
+		err = errors.Wrap(err, "failed to get positions")
+		return nil, err
+	} else if len(positions) != len(positionIDs) {
+		err = errors.New("position IDs do not match positions")
+		return nil, err
+	}
+
+	result := make([]*Position, 0, len(positions))
+	for idx, position := range positions {
+		if position.Quantity == nil || position.Quantity.Sign() == 0 {
+			continue
+		}
+
+		result = append(result, &Position{
+			TypesPosition: position,
+			ID:            positionIDs[idx],
+		})
+	}
+
+	return result, nil
+}
+
+// ClosePosition closes the given quantity of a position by taking the opposite order.
+func (cli *EthClient) ClosePosition(
+	call *CallArgs,
+	positionID *big.Int,
+	order wrappers.Order,
+	signature []byte,
+	quantity *big.Int,
+) (txHash common.Hash, err error) {
+	opts := cli.transactOpts(call)
+
+	err = cli.nonceCache.Serialize(opts.From, func() error {
+		nonce := cli.nonceCache.Incr(opts.From)
+		var resyncUsed bool
+
+		for {
+			opts.Nonce = big.NewInt(nonce)
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
+			opts.Context = ctx
+
+			tx, err := cli.futures.ClosePosition(opts, positionID, order, quantity, signature)
+			cancelFn()
+			if err != nil {
+				resyncUsed, err = cli.handleTxError(err, opts.From, resyncUsed)
+				if err != nil {
+					// unhandled error
+					return err
+				}
+
+				// try again with new nonce
+				nonce = cli.nonceCache.Incr(opts.From)
+				continue
+			}
+
+			txHash = tx.Hash()
+			return nil
+		}
+	})
+
+	return txHash, err
+}
+
+// MarketIndexPrice returns the index price of a market as recorded by the Futures contract.
+func (cli *EthClient) MarketIndexPrice(ctx context.Context, marketID common.Hash) (*big.Int, error) {
+	opts := &bind.CallOpts{
+		Context: ctx,
+		From:    cli.ContractAddress(EthContractFutures),
+	}
+
+	market, err := cli.futures.Markets(opts, marketID)
+	if err != nil {
+		return nil, err
+	}
+
+	return market.IndexPrice, nil
+}
+
+const oracleABI = `[{"constant":true,"inputs":[],"name":"latestAnswer","outputs":[{"name":"","type":"int256"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"payable":false,"stateMutability":"view","type":"function"}]`
+
+// OraclePrice reads the latest price reported by a market oracle, scaled to 18 decimals.
+func (cli *EthClient) OraclePrice(ctx context.Context, oracle common.Address) (*big.Int, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(oracleABI))
+	if err != nil {
+		return nil, err
+	}
+
+	contract := bind.NewBoundContract(oracle, parsedABI, cli.ethManager, cli.ethManager, cli.ethManager)
+	opts := &bind.CallOpts{
+		Context: ctx,
+	}
+
+	price := new(*big.Int)
+	if err := contract.Call(opts, price, "latestAnswer"); err != nil {
+		err = errors.Wrap(err, "failed to call oracle")
+		return nil, err
+	}
+
+	decimals, err := cli.oracleDecimalsOf(contract, opts, oracle)
+	if err != nil {
+		err = errors.Wrap(err, "failed to get oracle decimals")
+		return nil, err
+	}
+
+	return scaleTo18Decimals(*price, decimals), nil
+}
+
+// oracleDecimalsOf returns decimals of oracle answers, cached per oracle since they never change.
+// Feeds without decimals() are assumed to answer with 18 decimals, e.g. Chainlink USD feeds
+// have decimals() and answer with 8.
+func (cli *EthClient) oracleDecimalsOf(
+	contract *bind.BoundContract,
+	opts *bind.CallOpts,
+	oracle common.Address,
+) (uint8, error) {
+	cli.oracleDecimalsMux.RLock()
+	decimals, ok := cli.oracleDecimals[oracle]
+	cli.oracleDecimalsMux.RUnlock()
+
+	if ok {
+		return decimals, nil
+	}
+
+	if err := contract.Call(opts, &decimals, "decimals"); opts.Context.Err() != nil {
+		// a timeout tells nothing about the oracle, so nothing is cached
+		return 0, opts.Context.Err()
+	} else if err != nil {
+		logrus.WithError(err).Warningf("oracle %s has no decimals, assuming 18", oracle.Hex())
+		decimals = 18
+	}
+
+	cli.oracleDecimalsMux.Lock()
+	cli.oracleDecimals[oracle] = decimals
+	cli.oracleDecimalsMux.Unlock()
+
+	return decimals, nil
+}
+
+func scaleTo18Decimals(v *big.Int, decimals uint8) *big.Int {
+	switch {
+	case decimals < 18:
+		exp := big.NewInt(0).Exp(big.NewInt(10), big.NewInt(int64(18-decimals)), nil)
+		return big.NewInt(0).Mul(v, exp)
+	case decimals > 18:
+		exp := big.NewInt(0).Exp(big.NewInt(10), big.NewInt(int64(decimals-18)), nil)
+		return big.NewInt(0).Quo(v, exp)
+	default:
+		return v
+	}
+}
+
+// CalcMinMargin returns the minimum margin required by the market to open
+// a position of given quantity at the given contract price.
+func (cli *EthClient) CalcMinMargin(
+	ctx context.Context,
+	marketID common.Hash,
+	quantity, price *big.Int,
+) (*big.Int, error) {
+
+	opts := &bind.CallOpts{
+		Context: ctx,
+		From:    cli.ContractAddress(EthContractFutures),
+	}
+	return cli.futures.CalcMinMargin(opts, marketID, quantity, price)
+}
+
+func (cli *EthClient) GetZeroExOrderRelevantStates(
+	ctx context.Context,
+	orders []wrappers.Order,
+	signatures [][]byte,
+) (struct {
+	OrdersInfo                []wrappers.OrderInfo
+	FillableTakerAssetAmounts []*big.Int
+	IsValidSignature          []bool
+}, error) {
+
+	opts := &bind.CallOpts{
+		Context: ctx,
+		From:    cli.ContractAddress(EthContractDevUtils),
+	}
+
+	return cli.devUtils.GetOrderRelevantStates(opts, orders, signatures)
+}
+
+func (cli *EthClient) GetTransferableAssetAmount(
+	ctx context.Context,
+	ownerAddress common.Address,
+) (amount *big.Int, err error) {
+
+	opts := &bind.CallOpts{
+		Context: ctx,
+		From:    cli.ContractAddress(EthContractFutures),
+	}
+	return cli.futures.GetTransferableAssetAmount(opts, ownerAddress)
+}
+
+var futuresABI, _ = abi.JSON(strings.NewReader(wrappers.FuturesABI))
+
+// GetTransferableAssetAmounts returns amounts of base currency that can be used as margin
+// by each of the given accounts. Distinct accounts are queried in a single batch of calls.
+func (cli *EthClient) GetTransferableAssetAmounts(
+	ctx context.Context,
+	owners []common.Address,
+) (map[common.Address]*big.Int, error) {
+	amounts := make(map[common.Address]*big.Int, len(owners))
+	if len(owners) == 0 {
+		return amounts, nil
+	}
+
+	futuresAddress := cli.ContractAddress(EthContractFutures)
+
+	distinctOwners := make([]common.Address, 0, len(owners))
+	calls := make([]ethereum.CallMsg, 0, len(owners))
+	for _, owner := range owners {
+		if _, ok := amounts[owner]; ok {
+			continue
+		}
+		amounts[owner] = nil
+
+		data, err := futuresABI.Pack("getTransferableAssetAmount", owner)
+		if err != nil {
+			return nil, err
+		}
+
+		distinctOwners = append(distinctOwners, owner)
+		calls = append(calls, ethereum.CallMsg{
+			From: futuresAddress,
+			To:   &futuresAddress,
+			Data: data,
+		})
+	}
+
+	results, errs, err := cli.ethManager.BatchCallContract(ctx, calls)
+	if err != nil {
+		err = errors.Wrap(err, "failed to get transferable amounts")
 		return nil, err
 	}
 
-	signedTx, err := zeroex.SignTransaction(call.From, zeroex.NewLocalSigner(pk), tx)
+	for idx, owner := range distinctOwners {
+		if errs[idx] != nil {
+			err = errors.Wrapf(errs[idx], "failed to get transferable amount of %s", owner.Hex())
+			return nil, err
+		}
+
+		amount := new(*big.Int)
+		if err := futuresABI.Unpack(amount, "getTransferableAssetAmount", results[idx]); err != nil {
+			err = errors.Wrapf(err, "failed to get transferable amount of %s", owner.Hex())
+			return nil, err
+		}
+
+		amounts[owner] = *amount
+	}
+
+	return amounts, nil
+}
+
+func (cli *EthClient) chainID() *big.Int {
+	return big.NewInt(int64(cli.ethManager.ChainID()))
+}
+
+func (cli *EthClient) SignTransaction(call *CallArgs, tx *zeroex.Transaction) (*zeroex.SignedTransaction, error) {
+	signer := newKeystoreSigner(cli.keystore, call.FromPass, keystore.HashKindZeroExTx)
+
+	signedTx, err := zeroex.SignTransaction(call.From, signer, tx)
 	if err != nil {
 		err = errors.Wrap(err, "failed to sign transaction")
 		return nil, err
@@ -970,122 +1773,485 @@ func (cli *EthClient) erc20Wrapper(asset common.Address) (*wrappers.ERC20, error
 		err = errors.Wrap(err, "failed to init ERC20 contract wrapper")
 		return nil, err
 	}
-	cli.ercWrappers[asset] = wrapper
+	cli.ercWrappers[asset] = wrapper
+
+	return wrapper, nil
+}
+
+type AccountCreateArgs struct {
+	Password       string
+	PasswordRepeat string
+}
+
+func (a *AccountCreateArgs) check() error {
+	if a.Password != a.PasswordRepeat {
+		return errors.New("password repeat don't match")
+	}
+
+	if len(a.Password) < 8 {
+		return errors.New("password must be at least 8 symbols long")
+	}
+
+	return nil
+}
+
+// MinPassphraseLength is the minimum length of a passphrase that passes the strength check.
+const MinPassphraseLength = 12
+
+var weakPassphraseParts = []string{
+	"12345678", "87654321", "password", "passw0rd", "qwerty", "abcdefgh", "letmein", "dexterm",
+}
+
+// CheckPassphraseStrength rejects passphrases that are short or easy to guess.
+// Long passphrases of several words are fine without digits or symbols.
+func CheckPassphraseStrength(password string) error {
+	if len(password) < MinPassphraseLength {
+		return errors.Errorf("passphrase must be at least %d symbols long", MinPassphraseLength)
+	}
+
+	lower := strings.ToLower(password)
+	for _, part := range weakPassphraseParts {
+		if strings.Contains(lower, part) {
+			return errors.Errorf("passphrase contains a commonly used sequence: %s", part)
+		}
+	}
+
+	uniqueChars := make(map[rune]struct{})
+	var hasLetters, hasDigits, hasOthers bool
+	for _, c := range password {
+		uniqueChars[c] = struct{}{}
+
+		switch {
+		case unicode.IsLetter(c):
+			hasLetters = true
+		case unicode.IsDigit(c):
+			hasDigits = true
+		default:
+			hasOthers = true
+		}
+	}
+
+	if len(uniqueChars) < MinPassphraseLength/2 {
+		return errors.New("passphrase has too many repeated symbols")
+	}
+
+	var classes int
+	for _, has := range []bool{hasLetters, hasDigits, hasOthers} {
+		if has {
+			classes++
+		}
+	}
+
+	if classes < 2 && len(password) < 2*MinPassphraseLength {
+		return errors.New("passphrase must mix letters with digits or symbols, or be a longer phrase")
+	}
+
+	return nil
+}
+
+func CreateAccount(keystorePath string, args *AccountCreateArgs) (accounts.Account, error) {
+	if err := args.check(); err != nil {
+		return accounts.Account{}, err
+	}
+
+	return ethKeystore.StoreKey(
+		keystorePath,
+		args.Password,
+		ethKeystore.StandardScryptN,
+		ethKeystore.StandardScryptP,
+	)
+}
+
+type AccountImportArgs struct {
+	FilePath string
+}
+
+func ImportAccount(keystorePath string, args *AccountImportArgs) (common.Address, error) {
+	keyfilePath, err := homedir.Expand(args.FilePath)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	keyfileJSON, err := ioutil.ReadFile(keyfilePath)
+	if err != nil {
+		err = errors.Wrap(err, "unable to read keyfile")
+
+		return common.Address{}, err
+	}
+
+	var spec *WalletSpec
+	if err = json.Unmarshal(keyfileJSON, &spec); err != nil {
+		err = errors.Wrap(err, "unable to read keyfile")
+		return common.Address{}, err
+	}
+
+	if len(spec.Address) == 0 {
+		err := errors.Errorf("failed to load address from %s", keyfilePath)
+		return common.Address{}, err
+	}
+
+	info, err := os.Stat(keystorePath)
+	if err != nil {
+		err = errors.Wrap(err, "failed to check keystore")
+		return common.Address{}, err
+	} else if !info.IsDir() {
+		err = errors.Errorf("keystore path is not a dir: %s", keystorePath)
+		return common.Address{}, err
+	}
+
+	fileName := filepath.Base(keyfilePath)
+	err = ioutil.WriteFile(filepath.Join(keystorePath, fileName), keyfileJSON, 0600)
+	if err != nil {
+		err = errors.Wrap(err, "failed to copy keyfile into keystore")
+		return common.Address{}, err
+	}
+
+	return spec.HexToAddress(), nil
+}
+
+type AccountImportPrivKeyArgs struct {
+	PrivateKeyHex  string
+	Password       string
+	PasswordRepeat string
+}
+
+func ImportPrivKey(keystorePath string, args *AccountImportPrivKeyArgs) (common.Address, error) {
+	privKeyHex := strings.TrimPrefix(args.PrivateKeyHex, "0x")
+	pk, err := crypto.HexToECDSA(privKeyHex)
+	if err != nil {
+		err = errors.Wrap(err, "private key failed to parse")
+		return common.Address{}, err
+	}
+
+	if err := (&AccountCreateArgs{
+		Password:       args.Password,
+		PasswordRepeat: args.PasswordRepeat,
+	}).check(); err != nil {
+		return common.Address{}, err
+	}
+
+	ks := ethKeystore.NewKeyStore(
+		keystorePath,
+		ethKeystore.StandardScryptN,
+		ethKeystore.StandardScryptP,
+	)
+
+	acc, err := ks.ImportECDSA(pk, args.Password)
+	if err != nil {
+		err = errors.Wrap(err, "failed to import ECDSA private key")
+		return common.Address{}, err
+	}
+
+	return acc.Address, nil
+}
+
+// MaxMnemonicAccountIndex limits the number of accounts derived from a single mnemonic at once.
+const MaxMnemonicAccountIndex = 1000
+
+type AccountMnemonicArgs struct {
+	Password       string
+	PasswordRepeat string
+}
+
+// CreateMnemonicAccount generates a new BIP39 mnemonic and stores the first BIP44 account
+// derived from it as an encrypted keyfile.
+func CreateMnemonicAccount(keystorePath string, args *AccountMnemonicArgs) (string, common.Address, error) {
+	if err := (&AccountCreateArgs{
+		Password:       args.Password,
+		PasswordRepeat: args.PasswordRepeat,
+	}).check(); err != nil {
+		return "", common.Address{}, err
+	}
+
+	mnemonic, err := hdwallet.NewMnemonic()
+	if err != nil {
+		err = errors.Wrap(err, "failed to generate mnemonic")
+		return "", common.Address{}, err
+	}
+
+	addresses, err := ImportMnemonic(keystorePath, &AccountImportMnemonicArgs{
+		Mnemonic:       mnemonic,
+		Indices:        "0",
+		Password:       args.Password,
+		PasswordRepeat: args.PasswordRepeat,
+	})
+	if err != nil {
+		return "", common.Address{}, err
+	}
+
+	return mnemonic, addresses[0], nil
+}
+
+type AccountImportMnemonicArgs struct {
+	Mnemonic       string
+	Bip39Password  string
+	Indices        string
+	Password       string
+	PasswordRepeat string
+}
+
+// ImportMnemonic derives accounts along m/44'/60'/0'/0/i for the chosen indices
+// and stores them as encrypted keyfiles. Accounts already in the keystore are skipped.
+func ImportMnemonic(keystorePath string, args *AccountImportMnemonicArgs) ([]common.Address, error) {
+	wallet, err := hdwallet.NewFromMnemonic(normalizeMnemonic(args.Mnemonic), args.Bip39Password)
+	if err != nil {
+		return nil, err
+	}
+
+	indices, err := ParseAccountIndices(args.Indices)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := (&AccountCreateArgs{
+		Password:       args.Password,
+		PasswordRepeat: args.PasswordRepeat,
+	}).check(); err != nil {
+		return nil, err
+	}
+
+	ks := ethKeystore.NewKeyStore(
+		keystorePath,
+		ethKeystore.StandardScryptN,
+		ethKeystore.StandardScryptP,
+	)
+
+	addresses := make([]common.Address, 0, len(indices))
+	for _, idx := range indices {
+		pk, err := wallet.PrivateKey(idx)
+		if err != nil {
+			err = errors.Wrapf(err, "failed to derive account %s", hdwallet.AccountPath(idx))
+			return addresses, err
+		}
+
+		if addr := crypto.PubkeyToAddress(pk.PublicKey); ks.HasAddress(addr) {
+			addresses = append(addresses, addr)
+			continue
+		}
+
+		acc, err := ks.ImportECDSA(pk, args.Password)
+		if err != nil {
+			err = errors.Wrap(err, "failed to import derived private key")
+			return addresses, err
+		}
+
+		addresses = append(addresses, acc.Address)
+	}
+
+	return addresses, nil
+}
+
+// DeriveMnemonicAccounts derives count account addresses starting from the index, nothing is stored.
+func DeriveMnemonicAccounts(mnemonic, bip39Password string, from, count uint32) ([]common.Address, error) {
+	wallet, err := hdwallet.NewFromMnemonic(normalizeMnemonic(mnemonic), bip39Password)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]common.Address, 0, count)
+	for idx := from; idx < from+count; idx++ {
+		pk, err := wallet.PrivateKey(idx)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, crypto.PubkeyToAddress(pk.PublicKey))
+	}
 
-	return wrapper, nil
+	return addresses, nil
 }
 
-type AccountCreateArgs struct {
-	Password       string
-	PasswordRepeat string
-}
+// ParseAccountIndices parses a list of account indices like "0,2,5-7".
+func ParseAccountIndices(s string) ([]uint32, error) {
+	seen := make(map[uint32]struct{})
+	var indices []uint32
 
-func (a *AccountCreateArgs) check() error {
-	if a.Password != a.PasswordRepeat {
-		return errors.New("password repeat don't match")
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+
+		from, to := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			from, to = part[:i], part[i+1:]
+		}
+
+		fromIdx, err := strconv.ParseUint(strings.TrimSpace(from), 10, 32)
+		if err != nil {
+			err = errors.Errorf("wrong account index: %s", part)
+			return nil, err
+		}
+
+		toIdx, err := strconv.ParseUint(strings.TrimSpace(to), 10, 32)
+		if err != nil {
+			err = errors.Errorf("wrong account index: %s", part)
+			return nil, err
+		} else if toIdx < fromIdx {
+			err = errors.Errorf("wrong account index range: %s", part)
+			return nil, err
+		} else if toIdx >= MaxMnemonicAccountIndex {
+			err = errors.Errorf("account index must be less than %d", MaxMnemonicAccountIndex)
+			return nil, err
+		}
+
+		for idx := uint32(fromIdx); idx <= uint32(toIdx); idx++ {
+			if _, ok := seen[idx]; ok {
+				continue
+			}
+
+			seen[idx] = struct{}{}
+			indices = append(indices, idx)
+		}
 	}
 
-	if len(a.Password) < 8 {
-		return errors.New("password must be at least 8 symbols long")
+	if len(indices) == 0 {
+		return nil, errors.New("no account indices specified")
 	}
 
-	return nil
+	return indices, nil
 }
 
-func CreateAccount(keystorePath string, args *AccountCreateArgs) (accounts.Account, error) {
-	if err := args.check(); err != nil {
-		return accounts.Account{}, err
-	}
-
-	return ethKeystore.StoreKey(
-		keystorePath,
-		args.Password,
-		ethKeystore.StandardScryptN,
-		ethKeystore.StandardScryptP,
-	)
+func normalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
 }
 
-type AccountImportArgs struct {
-	FilePath string
+type AccountPasswdArgs struct {
+	Address           string
+	Password          string
+	NewPassword       string
+	NewPasswordRepeat string
+	ScryptN           string
+	ScryptP           string
 }
 
-func ImportAccount(keystorePath string, args *AccountImportArgs) (common.Address, error) {
-	keyfilePath, err := homedir.Expand(args.FilePath)
+// ErrWeakScryptParams is returned when scrypt N is below the light one, unless allowed explicitly.
+var ErrWeakScryptParams = errors.Errorf("scrypt N below %d makes the keyfile much easier to brute-force", ethKeystore.LightScryptN)
+
+// ChangeAccountPassphrase re-encrypts the keyfile of an account with a new password.
+// Empty scrypt params fall back to the standard ones, scrypt N below the light one
+// is rejected with ErrWeakScryptParams unless allowWeakScrypt is set.
+func ChangeAccountPassphrase(ks keystore.EthKeyStore, args *AccountPasswdArgs, allowWeakScrypt bool) (common.Address, error) {
+	account, err := ParseAccount(&AccountUseArgs{
+		Address: args.Address,
+	})
 	if err != nil {
 		return common.Address{}, err
 	}
 
-	keyfileJSON, err := ioutil.ReadFile(keyfilePath)
-	if err != nil {
-		err = errors.Wrap(err, "unable to read keyfile")
-
+	if err := (&AccountCreateArgs{
+		Password:       args.NewPassword,
+		PasswordRepeat: args.NewPasswordRepeat,
+	}).check(); err != nil {
 		return common.Address{}, err
+	} else if err := CheckPassphraseStrength(args.NewPassword); err != nil {
+		return common.Address{}, err
+	} else if args.NewPassword == args.Password {
+		return common.Address{}, errors.New("new passphrase must differ from the current one")
 	}
 
-	var spec *WalletSpec
-	if err = json.Unmarshal(keyfileJSON, &spec); err != nil {
-		err = errors.Wrap(err, "unable to read keyfile")
+	scryptN, err := parseScryptParam(args.ScryptN, ethKeystore.StandardScryptN)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse scrypt N")
 		return common.Address{}, err
 	}
 
-	if len(spec.Address) == 0 {
-		err := errors.Errorf("failed to load address from %s", keyfilePath)
+	scryptP, err := parseScryptParam(args.ScryptP, ethKeystore.StandardScryptP)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse scrypt P")
 		return common.Address{}, err
 	}
 
-	info, err := os.Stat(keystorePath)
-	if err != nil {
-		err = errors.Wrap(err, "failed to check keystore")
+	if scryptN&(scryptN-1) != 0 || scryptN < 2 {
+		err := errors.New("scrypt N must be a power of 2 greater than 1")
 		return common.Address{}, err
-	} else if !info.IsDir() {
-		err = errors.Errorf("keystore path is not a dir: %s", keystorePath)
+	} else if scryptN < ethKeystore.LightScryptN && !allowWeakScrypt {
+		return common.Address{}, ErrWeakScryptParams
+	}
+
+	if err := ks.ChangePassphrase(account, args.Password, args.NewPassword, scryptN, scryptP); err != nil {
+		err = errors.Wrap(err, "failed to change passphrase")
 		return common.Address{}, err
 	}
 
-	fileName := filepath.Base(keyfilePath)
-	err = ioutil.WriteFile(filepath.Join(keystorePath, fileName), keyfileJSON, 0600)
+	return account, nil
+}
+
+func parseScryptParam(v string, defaultValue int) (int, error) {
+	v = strings.TrimSpace(v)
+	if len(v) == 0 {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(v)
 	if err != nil {
-		err = errors.Wrap(err, "failed to copy keyfile into keystore")
-		return common.Address{}, err
+		return 0, err
+	} else if n <= 0 {
+		return 0, errors.New("value must be positive")
 	}
 
-	return spec.HexToAddress(), nil
+	return n, nil
 }
 
-type AccountImportPrivKeyArgs struct {
-	PrivateKeyHex  string
-	Password       string
-	PasswordRepeat string
+// ExportPrivateKey is a special export destination that reveals the raw private key.
+const ExportPrivateKey = "privkey"
+
+type AccountExportArgs struct {
+	Address     string
+	Destination string
+	Password    string
 }
 
-func ImportPrivKey(keystorePath string, args *AccountImportPrivKeyArgs) (common.Address, error) {
-	privKeyHex := strings.TrimPrefix(args.PrivateKeyHex, "0x")
-	pk, err := crypto.HexToECDSA(privKeyHex)
+// ExportKeyfile copies the encrypted keyfile of an account to the destination path.
+// If the destination is a dir, the original file name is kept. Existing files are never overwritten.
+// The passphrase is checked by decrypting the key, callers should UnsetKey it from ks when done.
+func ExportKeyfile(ks keystore.EthKeyStore, args *AccountExportArgs) (string, error) {
+	account, err := ParseAccount(&AccountUseArgs{
+		Address: args.Address,
+	})
 	if err != nil {
-		err = errors.Wrap(err, "private key failed to parse")
-		return common.Address{}, err
+		return "", err
 	}
 
-	if err := (&AccountCreateArgs{
-		Password:       args.Password,
-		PasswordRepeat: args.PasswordRepeat,
-	}).check(); err != nil {
-		return common.Address{}, err
+	spec, ok := ks.Wallet(account)
+	if !ok {
+		err := errors.Errorf("keyfile not found for %s", account.Hex())
+		return "", err
 	}
 
-	ks := ethKeystore.NewKeyStore(
-		keystorePath,
-		ethKeystore.StandardScryptN,
-		ethKeystore.StandardScryptP,
-	)
+	if _, ok := ks.PrivateKey(account, args.Password); !ok {
+		err := errors.New("failed to decrypt keyfile, check the passphrase")
+		return "", err
+	}
 
-	acc, err := ks.ImportECDSA(pk, args.Password)
+	keyfileJSON, err := ioutil.ReadFile(spec.Path)
 	if err != nil {
-		err = errors.Wrap(err, "failed to import ECDSA private key")
-		return common.Address{}, err
+		err = errors.Wrap(err, "unable to read keyfile")
+		return "", err
 	}
 
-	return acc.Address, nil
+	destPath, err := homedir.Expand(args.Destination)
+	if err != nil {
+		return "", err
+	}
+
+	if info, err := os.Stat(destPath); err == nil && info.IsDir() {
+		destPath = filepath.Join(destPath, filepath.Base(spec.Path))
+	}
+
+	destFile, err := os.OpenFile(destPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		err = errors.Wrap(err, "failed to create destination file")
+		return "", err
+	}
+	defer destFile.Close()
+
+	if _, err = destFile.Write(keyfileJSON); err != nil {
+		err = errors.Wrap(err, "failed to write keyfile")
+		return "", err
+	}
+
+	return destPath, nil
 }
 
 type WalletSpec struct {
@@ -1112,3 +2278,285 @@ func ParseAccount(args *AccountUseArgs) (common.Address, error) {
 
 	return addr, nil
 }
+
+// Message kinds supported by sign and verify.
+const (
+	MessageKindPersonal  = "personal"
+	MessageKindTypedData = "typed"
+	MessageKindOrder     = "order"
+)
+
+type AccountSignArgs struct {
+	Kind         string
+	Message      string
+	SignPassword string
+}
+
+type AccountVerifyArgs struct {
+	Kind      string
+	Message   string
+	Signature string
+	Address   string
+}
+
+// readMessage returns the message as is, or reads it from a file if prefixed with @.
+func readMessage(message string) ([]byte, error) {
+	if !strings.HasPrefix(message, "@") {
+		return []byte(message), nil
+	}
+
+	path, err := homedir.Expand(strings.TrimPrefix(message, "@"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		err = errors.Wrap(err, "unable to read message file")
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func readTypedData(message string) (*signercore.TypedData, error) {
+	data, err := readMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = normalizeTypedDataChainID(data)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse EIP-712 typed data JSON")
+		return nil, err
+	}
+
+	var typedData signercore.TypedData
+	if err := json.Unmarshal(data, &typedData); err != nil {
+		err = errors.Wrap(err, "failed to parse EIP-712 typed data JSON")
+		return nil, err
+	}
+
+	return &typedData, nil
+}
+
+// normalizeTypedDataChainID converts a numeric domain chainId into a string, since wallets
+// usually send it as a number, but geth expects a string.
+func normalizeTypedDataChainID(data []byte) ([]byte, error) {
+	var typedDataJSON map[string]json.RawMessage
+	if err := json.Unmarshal(data, &typedDataJSON); err != nil {
+		return nil, err
+	}
+
+	var domain map[string]interface{}
+	if err := json.Unmarshal(typedDataJSON["domain"], &domain); err != nil {
+		return data, nil
+	}
+
+	chainID, ok := domain["chainId"].(float64)
+	if !ok {
+		return data, nil
+	}
+
+	domain["chainId"] = strconv.FormatFloat(chainID, 'f', -1, 64)
+
+	domainJSON, err := json.Marshal(domain)
+	if err != nil {
+		return nil, err
+	}
+	typedDataJSON["domain"] = domainJSON
+
+	return json.Marshal(typedDataJSON)
+}
+
+func readSignedOrder(message string) (*zeroex.SignedOrder, error) {
+	data, err := readMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	var order zeroex.SignedOrder
+	if err := json.Unmarshal(data, &order); err != nil {
+		err = errors.Wrap(err, "failed to parse order JSON")
+		return nil, err
+	} else if order.ChainID == nil || order.MakerAssetAmount == nil || order.TakerAssetAmount == nil ||
+		order.MakerFee == nil || order.TakerFee == nil || order.ExpirationTimeSeconds == nil || order.Salt == nil {
+		err = errors.New("order JSON is missing required fields")
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// SignMessage signs a personal_sign message, EIP-712 typed data or a 0x order using the keystore.
+// Messages and typed data are signed in the [R || S || V] format where V is 27 or 28, orders get
+// the 0x EthSign signature, as they are signed for trading.
+func SignMessage(ks keystore.EthKeyStore, account common.Address, args *AccountSignArgs) ([]byte, error) {
+	var sig []byte
+
+	switch strings.TrimSpace(args.Kind) {
+	case MessageKindPersonal:
+		message, err := readMessage(args.Message)
+		if err != nil {
+			return nil, err
+		}
+
+		if sig, err = ks.SignText(account, args.SignPassword, message); err != nil {
+			return nil, err
+		}
+	case MessageKindTypedData:
+		typedData, err := readTypedData(args.Message)
+		if err != nil {
+			return nil, err
+		}
+
+		if sig, err = ks.SignTypedData(account, args.SignPassword, *typedData); err != nil {
+			return nil, err
+		}
+	case MessageKindOrder:
+		order, err := readSignedOrder(args.Message)
+		if err != nil {
+			return nil, err
+		} else if order.MakerAddress != account {
+			err = errors.Errorf("order maker is %s, not the signing account", order.MakerAddress.Hex())
+			return nil, err
+		}
+
+		signer := newKeystoreSigner(ks, args.SignPassword, keystore.HashKindOrder)
+		signedOrder, err := zeroex.SignOrder(signer, &order.Order)
+		if err != nil {
+			err = errors.Wrap(err, "failed to sign order")
+			return nil, err
+		}
+
+		return signedOrder.Signature, nil
+	default:
+		err := errors.Errorf("unsupported message kind: %s", args.Kind)
+		return nil, err
+	}
+
+	sig[64] += 27
+	return sig, nil
+}
+
+// VerifyMessage recovers the signer of a personal_sign message, EIP-712 typed data or a 0x order.
+// If the signature is empty for an order, the signature from its JSON is used.
+// If the address is set, the signer must match it.
+func VerifyMessage(args *AccountVerifyArgs) (common.Address, error) {
+	sig := common.FromHex(strings.TrimSpace(args.Signature))
+
+	var signer common.Address
+
+	switch strings.TrimSpace(args.Kind) {
+	case MessageKindPersonal:
+		message, err := readMessage(args.Message)
+		if err != nil {
+			return common.Address{}, err
+		}
+
+		if signer, err = recoverSigner(keystore.TextHash(message), sig); err != nil {
+			return common.Address{}, err
+		}
+	case MessageKindTypedData:
+		typedData, err := readTypedData(args.Message)
+		if err != nil {
+			return common.Address{}, err
+		}
+
+		hash, err := keystore.TypedDataHash(typedData)
+		if err != nil {
+			err = errors.Wrap(err, "failed to hash typed data")
+			return common.Address{}, err
+		}
+
+		if signer, err = recoverSigner(hash, sig); err != nil {
+			return common.Address{}, err
+		}
+	case MessageKindOrder:
+		order, err := readSignedOrder(args.Message)
+		if err != nil {
+			return common.Address{}, err
+		}
+
+		if len(sig) == 0 {
+			sig = order.Signature
+		}
+
+		if signer, err = recoverOrderSigner(&order.Order, sig); err != nil {
+			return common.Address{}, err
+		}
+
+		if len(strings.TrimSpace(args.Address)) == 0 && signer != order.MakerAddress {
+			err = errors.Errorf("order is signed by %s, not by its maker %s", signer.Hex(), order.MakerAddress.Hex())
+			return signer, err
+		}
+	default:
+		err := errors.Errorf("unsupported message kind: %s", args.Kind)
+		return common.Address{}, err
+	}
+
+	if len(strings.TrimSpace(args.Address)) > 0 {
+		expected, err := ParseAccount(&AccountUseArgs{
+			Address: strings.TrimSpace(args.Address),
+		})
+		if err != nil {
+			return signer, err
+		} else if signer != expected {
+			err = errors.Errorf("signature is made by %s, not by %s", signer.Hex(), expected.Hex())
+			return signer, err
+		}
+	}
+
+	return signer, nil
+}
+
+// recoverSigner recovers the signer from a [R || S || V] signature, V may be 0, 1, 27 or 28.
+func recoverSigner(hash common.Hash, sig []byte) (common.Address, error) {
+	if len(sig) != 65 {
+		err := errors.Errorf("expected a 65 byte signature, got %d bytes", len(sig))
+		return common.Address{}, err
+	}
+
+	recoverableSig := make([]byte, 65)
+	copy(recoverableSig, sig)
+	if recoverableSig[64] >= 27 {
+		recoverableSig[64] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(hash.Bytes(), recoverableSig)
+	if err != nil {
+		err = errors.Wrap(err, "failed to recover public key")
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// recoverOrderSigner recovers the signer of a 0x order signature in [V || R || S || type] format,
+// supporting the EIP712 and EthSign signature types.
+func recoverOrderSigner(order *zeroex.Order, sig []byte) (common.Address, error) {
+	if len(sig) != 66 {
+		err := errors.Errorf("expected a 66 byte 0x signature, got %d bytes", len(sig))
+		return common.Address{}, err
+	}
+
+	orderHash, err := order.ComputeOrderHash()
+	if err != nil {
+		err = errors.Wrap(err, "failed to compute order hash")
+		return common.Address{}, err
+	}
+
+	recoverableSig := make([]byte, 65)
+	copy(recoverableSig[:64], sig[1:65])
+	recoverableSig[64] = sig[0]
+
+	switch zeroex.SignatureType(sig[65]) {
+	case zeroex.EIP712Signature:
+		return recoverSigner(orderHash, recoverableSig)
+	case zeroex.EthSignSignature:
+		return recoverSigner(keystore.TextHash(orderHash.Bytes()), recoverableSig)
+	default:
+		err := errors.Errorf("unsupported 0x signature type: %d", sig[65])
+		return common.Address{}, err
+	}
+}
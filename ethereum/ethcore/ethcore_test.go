@@ -0,0 +1,94 @@
+package ethcore
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestScaleTo18Decimals(t *testing.T) {
+	testCases := []struct {
+		Value    int64
+		Decimals uint8
+		Expected string
+	}{
+		{12345, 8, "123450000000000"},
+		{12345, 18, "12345"},
+		{12345, 20, "123"},
+		{0, 8, "0"},
+		{-5, 17, "-50"},
+	}
+
+	for _, tc := range testCases {
+		v := big.NewInt(tc.Value)
+		scaled := scaleTo18Decimals(v, tc.Decimals)
+
+		if scaled.String() != tc.Expected {
+			t.Errorf("%d with %d decimals: expected %s, got %s", tc.Value, tc.Decimals, tc.Expected, scaled)
+		}
+		if v.Int64() != tc.Value {
+			t.Errorf("%d with %d decimals: input has been modified", tc.Value, tc.Decimals)
+		}
+	}
+}
+
+func TestParseAccountIndices(t *testing.T) {
+	testCases := []struct {
+		Value    string
+		Expected []uint32
+		Err      bool
+	}{
+		{"0", []uint32{0}, false},
+		{"0,2,5-7", []uint32{0, 2, 5, 6, 7}, false},
+		{" 3 - 4 , 1,", []uint32{3, 4, 1}, false},
+		{"1-3,2", []uint32{1, 2, 3}, false},
+		{"999", []uint32{999}, false},
+		{"1000", nil, true},
+		{"5-3", nil, true},
+		{"-1", nil, true},
+		{"a", nil, true},
+		{",", nil, true},
+	}
+
+	for _, tc := range testCases {
+		indices, err := ParseAccountIndices(tc.Value)
+		if tc.Err {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", tc.Value, indices)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.Value, err)
+			continue
+		}
+
+		if fmt.Sprint(indices) != fmt.Sprint(tc.Expected) {
+			t.Errorf("%q: expected %v, got %v", tc.Value, tc.Expected, indices)
+		}
+	}
+}
+
+func TestCheckPassphraseStrength(t *testing.T) {
+	testCases := []struct {
+		Password string
+		Ok       bool
+	}{
+		{"short1!", false},
+		{"my12345678pass", false},
+		{"MyPassword-Is-Long", false},
+		{"aaaaaabbbbbb1", false},
+		{"lettersonlyhere", false},
+		{"correct horse battery staple", true},
+		{"Tr0ub4dor&3xyz", true},
+		{"k9v2m7q4x8z1", true},
+	}
+
+	for _, tc := range testCases {
+		err := CheckPassphraseStrength(tc.Password)
+		if tc.Ok && err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.Password, err)
+		} else if !tc.Ok && err == nil {
+			t.Errorf("%q: expected passphrase to be rejected", tc.Password)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package ethcore
+
+import (
+	"context"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// Nodes limit either the block range or the number of results of a single logs query,
+// so long ranges are queried in chunks. Queries by an indexed account match few logs
+// and use larger chunks.
+const (
+	indexedLogsChunkSize   = 100000
+	unindexedLogsChunkSize = 5000
+)
+
+// filterLogsInChunks runs the query over blocks from fromBlock to toBlock inclusive,
+// at most chunkSize blocks at a time, starting from the most recent blocks. Logs found
+// before a failure are returned along with the error, which tells the blocks not scanned.
+func (cli *EthClient) filterLogsInChunks(
+	ctx context.Context,
+	query ethereum.FilterQuery,
+	fromBlock, toBlock, chunkSize uint64,
+) ([]types.Log, error) {
+	if fromBlock > toBlock {
+		return nil, nil
+	}
+
+	var logs []types.Log
+
+	for to := toBlock; ; to -= chunkSize {
+		from := fromBlock
+		if to-fromBlock >= chunkSize {
+			from = to - chunkSize + 1
+		}
+
+		query.FromBlock = big.NewInt(0).SetUint64(from)
+		query.ToBlock = big.NewInt(0).SetUint64(to)
+
+		chunk, err := cli.ethManager.FilterLogs(ctx, query)
+		if err != nil {
+			err = errors.Wrapf(err, "failed to filter logs, blocks %d-%d are not scanned", fromBlock, to)
+			return logs, err
+		}
+
+		logs = append(logs, chunk...)
+
+		if from == fromBlock {
+			return logs, nil
+		}
+	}
+}
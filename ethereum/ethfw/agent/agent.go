@@ -0,0 +1,203 @@
+// Package agent implements a local key agent that keeps unlocked keys in memory
+// and serves signing requests over a Unix socket, similar to ssh-agent.
+package agent
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
+)
+
+const (
+	MethodAccounts = "accounts"
+	MethodSignHash = "sign_hash"
+)
+
+var (
+	ErrUnknownAccount = errors.New("account is not loaded into agent")
+	ErrUnknownMethod  = errors.New("unknown agent method")
+	ErrPeerNotAllowed = errors.New("peer is not allowed to use the agent")
+)
+
+// Request is a single newline-delimited JSON request sent to the agent.
+type Request struct {
+	Method  string            `json:"method"`
+	Account common.Address    `json:"account,omitempty"`
+	Kind    keystore.HashKind `json:"kind,omitempty"`
+	Hash    common.Hash       `json:"hash,omitempty"`
+}
+
+// Response is a single newline-delimited JSON response of the agent.
+type Response struct {
+	Accounts  []common.Address `json:"accounts,omitempty"`
+	Signature hexutil.Bytes    `json:"signature,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// Agent holds unlocked keys in memory and signs hashes for the local peers.
+type Agent struct {
+	keys    map[common.Address]*ecdsa.PrivateKey
+	keysMux *sync.RWMutex
+}
+
+func New() *Agent {
+	return &Agent{
+		keys:    make(map[common.Address]*ecdsa.PrivateKey),
+		keysMux: new(sync.RWMutex),
+	}
+}
+
+func (a *Agent) AddKey(key *ecdsa.PrivateKey) common.Address {
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	a.keysMux.Lock()
+	a.keys[account] = key
+	a.keysMux.Unlock()
+	return account
+}
+
+// Wipe removes all keys from the agent.
+func (a *Agent) Wipe() {
+	a.keysMux.Lock()
+	for account, key := range a.keys {
+		key.D.SetInt64(0)
+		delete(a.keys, account)
+	}
+	a.keysMux.Unlock()
+}
+
+func (a *Agent) Accounts() []common.Address {
+	a.keysMux.RLock()
+	accounts := make([]common.Address, 0, len(a.keys))
+	for account := range a.keys {
+		accounts = append(accounts, account)
+	}
+	a.keysMux.RUnlock()
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Hex() < accounts[j].Hex()
+	})
+	return accounts
+}
+
+func (a *Agent) SignHash(account common.Address, kind keystore.HashKind, hash common.Hash) ([]byte, error) {
+	a.keysMux.RLock()
+	key, ok := a.keys[account]
+	a.keysMux.RUnlock()
+	if !ok {
+		return nil, ErrUnknownAccount
+	}
+	return keystore.SignHashWithKey(key, kind, hash)
+}
+
+// Listen creates the agent socket. The parent dir must not be accessible by other users,
+// the socket itself is accessible by the owner only.
+func Listen(socketPath string) (net.Listener, error) {
+	dir := filepath.Dir(socketPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("socket dir %s must not be accessible by other users (mode %s)", dir, info.Mode().Perm())
+	}
+	if _, err := os.Stat(socketPath); err == nil {
+		// remove a stale socket, but never steal one from a running agent
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("agent is already running on %s", socketPath)
+		}
+		if err := os.Remove(socketPath); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve accepts connections until the listener is closed.
+func (a *Agent) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return err
+		}
+		go a.handleConn(conn)
+	}
+}
+
+func (a *Agent) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	if err := checkPeer(conn); err != nil {
+		logrus.WithError(err).Warningln("rejected agent connection")
+		return
+	}
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			if err != io.EOF {
+				logrus.WithError(err).Debugln("failed to read agent request")
+			}
+			return
+		}
+		if err := enc.Encode(a.handle(&req)); err != nil {
+			logrus.WithError(err).Debugln("failed to write agent response")
+			return
+		}
+	}
+}
+
+func (a *Agent) handle(req *Request) *Response {
+	switch req.Method {
+	case MethodAccounts:
+		return &Response{
+			Accounts: a.Accounts(),
+		}
+	case MethodSignHash:
+		sig, err := a.SignHash(req.Account, req.Kind, req.Hash)
+		if err != nil {
+			return &Response{
+				Error: err.Error(),
+			}
+		}
+		logrus.WithFields(logrus.Fields{
+			"account": req.Account.Hex(),
+			"kind":    req.Kind,
+			"hash":    req.Hash.Hex(),
+		}).Infoln("signed hash")
+		return &Response{
+			Signature: sig,
+		}
+	default:
+		return &Response{
+			Error: ErrUnknownMethod.Error(),
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
+)
+
+func TestAgentKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New()
+	account := a.AddKey(key)
+
+	socketPath := filepath.Join(dir, "agent.sock")
+	l, err := Listen(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go a.Serve(l)
+
+	client := NewClient(socketPath)
+	defer client.Close()
+	ks := NewKeyStore(client)
+
+	if accs := ks.Accounts(); len(accs) != 1 || accs[0] != account {
+		t.Fatalf("unexpected agent accounts: %v", accs)
+	}
+
+	hash := common.HexToHash("0x1234")
+	sig, err := ks.SignHash(account, "", keystore.HashKindOrder, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(hash.Bytes()), sig)
+	if err != nil {
+		t.Fatal(err)
+	} else if crypto.PubkeyToAddress(*pub) != account {
+		t.Fatal("order hash signed by a wrong key")
+	}
+
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	tx := types.NewTransaction(0, account, big.NewInt(1), 21000, big.NewInt(1), nil)
+	signedTx, err := ks.SignerFn(account, "")(signer, account, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender, err := types.Sender(signer, signedTx); err != nil {
+		t.Fatal(err)
+	} else if sender != account {
+		t.Fatal("tx signed by a wrong key")
+	}
+
+	if _, err := ks.SignHash(common.HexToAddress("0x1"), "", keystore.HashKindOrder, hash); err == nil {
+		t.Fatal("expected error for an unknown account")
+	}
+
+	a.Wipe()
+	if _, err := ks.SignHash(account, "", keystore.HashKindOrder, hash); err == nil {
+		t.Fatal("expected error after wipe")
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
+)
+
+const requestTimeout = 10 * time.Second
+
+// Client talks to a running agent, the connection is re-established on failures.
+type Client struct {
+	socketPath string
+
+	mux  *sync.Mutex
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
+
+func NewClient(socketPath string) *Client {
+	return &Client{
+		socketPath: socketPath,
+		mux:        new(sync.Mutex),
+	}
+}
+
+func (c *Client) Accounts() ([]common.Address, error) {
+	resp, err := c.call(&Request{
+		Method: MethodAccounts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Accounts, nil
+}
+
+func (c *Client) SignHash(account common.Address, kind keystore.HashKind, hash common.Hash) ([]byte, error) {
+	resp, err := c.call(&Request{
+		Method:  MethodSignHash,
+		Account: account,
+		Kind:    kind,
+		Hash:    hash,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(resp.Signature) != 65 {
+		return nil, errors.New("agent returned a malformed signature")
+	}
+	return resp.Signature, nil
+}
+
+func (c *Client) Close() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.closeConn()
+}
+
+func (c *Client) call(req *Request) (*Response, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	resp, err := c.roundTrip(req)
+	if err != nil {
+		// the agent might have been restarted, retry once with a new connection
+		c.closeConn()
+		if resp, err = c.roundTrip(req); err != nil {
+			c.closeConn()
+			return nil, err
+		}
+	}
+	if len(resp.Error) > 0 {
+		return nil, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+func (c *Client) roundTrip(req *Request) (*Response, error) {
+	if c.conn == nil {
+		conn, err := net.DialTimeout("unix", c.socketPath, requestTimeout)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+		c.enc = json.NewEncoder(conn)
+		c.dec = json.NewDecoder(conn)
+	}
+	c.conn.SetDeadline(time.Now().Add(requestTimeout))
+	if err := c.enc.Encode(req); err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := c.dec.Decode(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) closeConn() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	c.enc = nil
+	c.dec = nil
+	return err
+}
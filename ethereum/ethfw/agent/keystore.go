@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	signercore "github.com/ethereum/go-ethereum/signer/core"
+	"github.com/sirupsen/logrus"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
+)
+
+var ErrNotSupported = errors.New("not supported by agent keystore")
+
+// NewKeyStore returns a keystore backed by a running agent. Keys never leave the agent,
+// passwords are ignored since the agent holds the keys unlocked.
+func NewKeyStore(client *Client) keystore.EthKeyStore {
+	return &agentKeyStore{
+		client: client,
+	}
+}
+
+type agentKeyStore struct {
+	client *Client
+}
+
+func (ks *agentKeyStore) PrivateKey(account common.Address, password string) (key *ecdsa.PrivateKey, ok bool) {
+	return nil, false
+}
+
+func (ks *agentKeyStore) SignerFn(account common.Address, password string) bind.SignerFn {
+	return func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != account {
+			return nil, errors.New("not authorized to sign this account")
+		}
+		signature, err := ks.client.SignHash(account, keystore.HashKindEthTx, signer.Hash(tx))
+		if err != nil {
+			return nil, err
+		}
+		return tx.WithSignature(signer, signature)
+	}
+}
+
+func (ks *agentKeyStore) SignHash(account common.Address, password string, kind keystore.HashKind, hash common.Hash) ([]byte, error) {
+	return ks.client.SignHash(account, kind, hash)
+}
+
+func (ks *agentKeyStore) SignTypedData(account common.Address, password string, typedData signercore.TypedData) ([]byte, error) {
+	hash, err := keystore.TypedDataHash(&typedData)
+	if err != nil {
+		return nil, err
+	}
+	return ks.client.SignHash(account, keystore.HashKindTypedData, hash)
+}
+
+func (ks *agentKeyStore) SignText(account common.Address, password string, text []byte) ([]byte, error) {
+	return ks.client.SignHash(account, keystore.HashKindText, keystore.TextHash(text))
+}
+
+func (ks *agentKeyStore) UnsetKey(account common.Address, password string) {}
+
+func (ks *agentKeyStore) Accounts() []common.Address {
+	accounts, err := ks.client.Accounts()
+	if err != nil {
+		logrus.WithError(err).Warningln("failed to list agent accounts")
+		return nil
+	}
+	return accounts
+}
+
+func (ks *agentKeyStore) Wallet(account common.Address) (spec *keystore.WalletSpec, ok bool) {
+	return nil, false
+}
+
+func (ks *agentKeyStore) ChangePassphrase(account common.Address, password, newPassword string, scryptN, scryptP int) error {
+	return ErrNotSupported
+}
+
+func (ks *agentKeyStore) AddPath(keybase string) error {
+	return ErrNotSupported
+}
+
+func (ks *agentKeyStore) RemovePath(keybase string) {}
+
+func (ks *agentKeyStore) Paths() []string {
+	return nil
+}
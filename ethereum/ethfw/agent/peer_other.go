@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package agent
+
+import "net"
+
+// checkPeer relies on the socket file permissions only, peer credentials are checked on Linux.
+func checkPeer(conn net.Conn) error {
+	if _, ok := conn.(*net.UnixConn); !ok {
+		return ErrPeerNotAllowed
+	}
+	return nil
+}
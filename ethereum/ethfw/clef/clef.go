@@ -0,0 +1,206 @@
+// Package clef implements a keystore that forwards signing to an external
+// Clef-compatible signer over HTTP or IPC, so keys never reach the trading host.
+package clef
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	signercore "github.com/ethereum/go-ethereum/signer/core"
+	"github.com/sirupsen/logrus"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
+)
+
+// SignerPrefix is the prefix of signer specs in config, e.g. "clef:/home/user/.clef/clef.ipc"
+// or "clef:http://localhost:8550".
+const SignerPrefix = "clef:"
+
+// requestTimeout is generous since the signer may wait for a manual approval.
+const requestTimeout = 5 * time.Minute
+
+var (
+	ErrNotSupported   = errors.New("not supported by remote signer")
+	ErrBadSignature   = errors.New("remote signer returned a malformed signature")
+	ErrTxMismatch     = errors.New("remote signer returned a different transaction")
+	ErrWrongSignerKey = errors.New("remote signer signed with a wrong key")
+)
+
+// ParseSignerSpec extracts the signer endpoint from a "clef:<endpoint>" spec.
+func ParseSignerSpec(spec string) (string, error) {
+	if !strings.HasPrefix(spec, SignerPrefix) {
+		return "", fmt.Errorf("unsupported signer: %s", spec)
+	}
+	endpoint := strings.TrimPrefix(spec, SignerPrefix)
+	if len(endpoint) == 0 {
+		return "", errors.New("signer endpoint is empty")
+	}
+	return endpoint, nil
+}
+
+// NewKeyStore connects to a signer endpoint, that can be an HTTP(s) URL or an IPC socket path.
+// Passwords are ignored since the signer asks for approval on its own.
+func NewKeyStore(endpoint string) (keystore.EthKeyStore, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &clefKeyStore{
+		client: client,
+	}, nil
+}
+
+type clefKeyStore struct {
+	client *rpc.Client
+}
+
+// sendTxArgs mirrors the transaction arguments accepted by account_signTransaction.
+type sendTxArgs struct {
+	From     common.MixedcaseAddress  `json:"from"`
+	To       *common.MixedcaseAddress `json:"to"`
+	Gas      hexutil.Uint64           `json:"gas"`
+	GasPrice hexutil.Big              `json:"gasPrice"`
+	Value    hexutil.Big              `json:"value"`
+	Nonce    hexutil.Uint64           `json:"nonce"`
+	Data     *hexutil.Bytes           `json:"data"`
+}
+
+type signTxResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+func (ks *clefKeyStore) PrivateKey(account common.Address, password string) (key *ecdsa.PrivateKey, ok bool) {
+	return nil, false
+}
+
+func (ks *clefKeyStore) SignerFn(account common.Address, password string) bind.SignerFn {
+	return func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != account {
+			return nil, errors.New("not authorized to sign this account")
+		}
+		args := &sendTxArgs{
+			From:     common.NewMixedcaseAddress(account),
+			Gas:      hexutil.Uint64(tx.Gas()),
+			GasPrice: hexutil.Big(*tx.GasPrice()),
+			Value:    hexutil.Big(*tx.Value()),
+			Nonce:    hexutil.Uint64(tx.Nonce()),
+		}
+		if to := tx.To(); to != nil {
+			mixedTo := common.NewMixedcaseAddress(*to)
+			args.To = &mixedTo
+		}
+		if data := tx.Data(); len(data) > 0 {
+			input := hexutil.Bytes(data)
+			args.Data = &input
+		}
+
+		var result signTxResult
+		if err := ks.call(&result, "account_signTransaction", args); err != nil {
+			return nil, err
+		}
+		signedTx := new(types.Transaction)
+		if err := rlp.DecodeBytes(result.Raw, signedTx); err != nil {
+			return nil, err
+		}
+		// the signer may use another chain ID or alter the tx, never broadcast that
+		if signer.Hash(signedTx) != signer.Hash(tx) {
+			return nil, ErrTxMismatch
+		}
+		if sender, err := types.Sender(signer, signedTx); err != nil {
+			return nil, err
+		} else if sender != account {
+			return nil, ErrWrongSignerKey
+		}
+		return signedTx, nil
+	}
+}
+
+// SignHash signs order and 0x transaction hashes as text messages, that's the same as eth_sign.
+// Signing bare hashes is not supported by the signer.
+func (ks *clefKeyStore) SignHash(account common.Address, password string, kind keystore.HashKind, hash common.Hash) ([]byte, error) {
+	if !kind.IsMessage() {
+		return nil, ErrNotSupported
+	}
+	var sig hexutil.Bytes
+	if err := ks.call(&sig, "account_signData", "text/plain", account, hexutil.Bytes(hash.Bytes())); err != nil {
+		return nil, err
+	}
+	return toRecoverableSig(sig)
+}
+
+func (ks *clefKeyStore) SignTypedData(account common.Address, password string, typedData signercore.TypedData) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := ks.call(&sig, "account_signTypedData", account, typedData); err != nil {
+		return nil, err
+	}
+	return toRecoverableSig(sig)
+}
+
+func (ks *clefKeyStore) SignText(account common.Address, password string, text []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := ks.call(&sig, "account_signData", "text/plain", account, hexutil.Bytes(text)); err != nil {
+		return nil, err
+	}
+	return toRecoverableSig(sig)
+}
+
+func (ks *clefKeyStore) UnsetKey(account common.Address, password string) {}
+
+func (ks *clefKeyStore) Accounts() []common.Address {
+	var accounts []common.Address
+	if err := ks.call(&accounts, "account_list"); err != nil {
+		logrus.WithError(err).Warningln("failed to list remote signer accounts")
+		return nil
+	}
+	return accounts
+}
+
+func (ks *clefKeyStore) Wallet(account common.Address) (spec *keystore.WalletSpec, ok bool) {
+	return nil, false
+}
+
+func (ks *clefKeyStore) ChangePassphrase(account common.Address, password, newPassword string, scryptN, scryptP int) error {
+	return ErrNotSupported
+}
+
+func (ks *clefKeyStore) AddPath(keybase string) error {
+	return ErrNotSupported
+}
+
+func (ks *clefKeyStore) RemovePath(keybase string) {}
+
+func (ks *clefKeyStore) Paths() []string {
+	return nil
+}
+
+func (ks *clefKeyStore) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancelFn()
+	return ks.client.CallContext(ctx, result, method, args...)
+}
+
+// toRecoverableSig converts the signature with V = 27 or 28 into the [R || S || V] format where V is 0 or 1.
+func toRecoverableSig(sig []byte) ([]byte, error) {
+	if len(sig) != 65 {
+		return nil, ErrBadSignature
+	}
+	out := make([]byte, 65)
+	copy(out, sig)
+	if out[64] >= 27 {
+		out[64] -= 27
+	}
+	if out[64] > 1 {
+		return nil, ErrBadSignature
+	}
+	return out, nil
+}
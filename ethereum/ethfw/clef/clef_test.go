@@ -0,0 +1,170 @@
+package clef
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	signercore "github.com/ethereum/go-ethereum/signer/core"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
+)
+
+// standInSigner serves a subset of the Clef external API, approving everything.
+type standInSigner struct {
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+}
+
+func (s *standInSigner) List(ctx context.Context) ([]common.Address, error) {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}, nil
+}
+
+func (s *standInSigner) SignTransaction(ctx context.Context, args sendTxArgs, methodSelector *string) (*signTxResult, error) {
+	var data []byte
+	if args.Data != nil {
+		data = *args.Data
+	}
+	tx := types.NewTransaction(uint64(args.Nonce), args.To.Address(), (*big.Int)(&args.Value), uint64(args.Gas), (*big.Int)(&args.GasPrice), data)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(s.chainID), s.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rlp.EncodeToBytes(signedTx)
+	if err != nil {
+		return nil, err
+	}
+	return &signTxResult{Raw: raw}, nil
+}
+
+func (s *standInSigner) SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	return s.sign(accounts.TextHash(data))
+}
+
+func (s *standInSigner) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, typedData signercore.TypedData) (hexutil.Bytes, error) {
+	hash, err := keystore.TypedDataHash(&typedData)
+	if err != nil {
+		return nil, err
+	}
+	return s.sign(hash.Bytes())
+}
+
+func (s *standInSigner) sign(digest []byte) (hexutil.Bytes, error) {
+	sig, err := crypto.Sign(digest, s.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+func TestClefKeyStore(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(1)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &standInSigner{key: key, chainID: chainID}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	endpoint, err := ParseSignerSpec(SignerPrefix + httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := NewKeyStore(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if accs := ks.Accounts(); len(accs) != 1 || accs[0] != account {
+		t.Fatalf("unexpected signer accounts: %v", accs)
+	}
+
+	signer := types.NewEIP155Signer(chainID)
+	tx := types.NewTransaction(1, common.HexToAddress("0x2"), big.NewInt(1), 21000, big.NewInt(1), []byte{0x1})
+	signedTx, err := ks.SignerFn(account, "")(signer, account, tx)
+	if err != nil {
+		t.Fatal(err)
+	} else if sender, _ := types.Sender(signer, signedTx); sender != account {
+		t.Fatal("tx signed by a wrong key")
+	}
+	if _, err := ks.SignerFn(account, "")(types.NewEIP155Signer(big.NewInt(42)), account, tx); err == nil {
+		t.Fatal("expected error for a tx signed for another chain")
+	}
+
+	hash := common.HexToHash("0x1234")
+	sig, err := ks.SignHash(account, "", keystore.HashKindOrder, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := crypto.SigToPub(accounts.TextHash(hash.Bytes()), sig); err != nil {
+		t.Fatal(err)
+	} else if crypto.PubkeyToAddress(*pub) != account {
+		t.Fatal("order hash signed by a wrong key")
+	}
+	if _, err := ks.SignHash(account, "", keystore.HashKindEthTx, hash); err != ErrNotSupported {
+		t.Fatalf("expected ErrNotSupported for bare hashes, got %v", err)
+	}
+
+	text := []byte("I own this address")
+	sig, err = ks.SignText(account, "", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := crypto.SigToPub(accounts.TextHash(text), sig); err != nil {
+		t.Fatal(err)
+	} else if crypto.PubkeyToAddress(*pub) != account {
+		t.Fatal("text signed by a wrong key")
+	}
+
+	typedData := signercore.TypedData{
+		Types: signercore.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"Person": {
+				{Name: "name", Type: "string"},
+				{Name: "wallet", Type: "address"},
+			},
+		},
+		PrimaryType: "Person",
+		Domain: signercore.TypedDataDomain{
+			Name:    "dexterm",
+			ChainId: math.NewHexOrDecimal256(1),
+		},
+		Message: signercore.TypedDataMessage{
+			"name":   "Bob",
+			"wallet": account.Hex(),
+		},
+	}
+	sig, err = ks.SignTypedData(account, "", typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typedHash, err := keystore.TypedDataHash(&typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := crypto.SigToPub(typedHash.Bytes(), sig); err != nil {
+		t.Fatal(err)
+	} else if crypto.PubkeyToAddress(*pub) != account {
+		t.Fatal("typed data signed by a wrong key")
+	}
+}
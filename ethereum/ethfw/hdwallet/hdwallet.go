@@ -0,0 +1,133 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// BasePath is the BIP44 prefix for Ethereum accounts, the account index is appended to it.
+const BasePath = "m/44'/60'/0'/0"
+
+var (
+	ErrInvalidMnemonic = errors.New("invalid BIP39 mnemonic")
+	ErrInvalidChildKey = errors.New("derived child key is invalid, use the next index")
+)
+
+var masterKeySalt = []byte("Bitcoin seed")
+
+// NewMnemonic generates a new 24-word BIP39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(256)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// Wallet is a BIP32 HD wallet created from a BIP39 seed.
+type Wallet struct {
+	masterKey   []byte
+	masterChain []byte
+}
+
+// NewFromMnemonic validates the mnemonic and creates a wallet from its seed,
+// passphrase is the optional BIP39 passphrase (the 25th word).
+func NewFromMnemonic(mnemonic, passphrase string) (*Wallet, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, ErrInvalidMnemonic
+	}
+	return NewFromSeed(seed)
+}
+
+// NewFromSeed creates a wallet from a raw BIP32 seed.
+func NewFromSeed(seed []byte) (*Wallet, error) {
+	mac := hmac.New(sha512.New, masterKeySalt)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	if !isValidKey(sum[:32]) {
+		return nil, errors.New("seed produces an invalid master key")
+	}
+	return &Wallet{
+		masterKey:   sum[:32],
+		masterChain: sum[32:],
+	}, nil
+}
+
+// AccountPath returns the BIP44 derivation path for the account index.
+func AccountPath(index uint32) string {
+	return fmt.Sprintf("%s/%d", BasePath, index)
+}
+
+// PrivateKey derives the private key of the account at m/44'/60'/0'/0/index.
+func (w *Wallet) PrivateKey(index uint32) (*ecdsa.PrivateKey, error) {
+	return w.Derive(AccountPath(index))
+}
+
+// Derive derives the private key along an arbitrary derivation path.
+func (w *Wallet) Derive(path string) (*ecdsa.PrivateKey, error) {
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	key, chain := w.masterKey, w.masterChain
+	for _, n := range derivationPath {
+		if key, chain, err = deriveChild(key, chain, n); err != nil {
+			return nil, err
+		}
+	}
+	return crypto.ToECDSA(key)
+}
+
+// deriveChild implements the private parent key → private child key function of BIP32.
+func deriveChild(key, chain []byte, n uint32) ([]byte, []byte, error) {
+	data := make([]byte, 0, 37)
+	if n >= 0x80000000 {
+		data = append(data, 0x0)
+		data = append(data, key...)
+	} else {
+		pk, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = append(data, crypto.CompressPubkey(&pk.PublicKey)...)
+	}
+	var idx [4]byte
+	binary.BigEndian.PutUint32(idx[:], n)
+	data = append(data, idx[:]...)
+
+	mac := hmac.New(sha512.New, chain)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(sum[:32])
+	curveN := crypto.S256().Params().N
+	if il.Cmp(curveN) >= 0 {
+		return nil, nil, ErrInvalidChildKey
+	}
+	childKey := il.Add(il, new(big.Int).SetBytes(key))
+	childKey.Mod(childKey, curveN)
+	if childKey.Sign() == 0 {
+		return nil, nil, ErrInvalidChildKey
+	}
+
+	childKeyBytes := make([]byte, 32)
+	b := childKey.Bytes()
+	copy(childKeyBytes[32-len(b):], b)
+	return childKeyBytes, sum[32:], nil
+}
+
+func isValidKey(key []byte) bool {
+	k := new(big.Int).SetBytes(key)
+	return k.Sign() > 0 && k.Cmp(crypto.S256().Params().N) < 0
+}
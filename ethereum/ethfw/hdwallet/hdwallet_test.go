@@ -0,0 +1,33 @@
+package hdwallet
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPrivateKey(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	expected := []string{
+		"0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+	}
+
+	w, err := NewFromMnemonic(mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, addr := range expected {
+		pk, err := w.PrivateKey(uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if derived := crypto.PubkeyToAddress(pk.PublicKey).Hex(); derived != addr {
+			t.Fatalf("index %d: expected %s, got %s", i, addr, derived)
+		}
+	}
+
+	if _, err := NewFromMnemonic("abandon abandon abandon", ""); err != ErrInvalidMnemonic {
+		t.Fatalf("expected ErrInvalidMnemonic, got %v", err)
+	}
+}
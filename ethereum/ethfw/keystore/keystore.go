@@ -9,31 +9,64 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/InjectiveLabs/dexterm/ethereum/ethfw"
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethKeystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	signercore "github.com/ethereum/go-ethereum/signer/core"
 )
 
 type EthKeyStore interface {
 	PrivateKey(account common.Address, password string) (key *ecdsa.PrivateKey, ok bool)
 	SignerFn(account common.Address, password string) bind.SignerFn
+	SignHash(account common.Address, password string, kind HashKind, hash common.Hash) ([]byte, error)
+	SignTypedData(account common.Address, password string, typedData signercore.TypedData) ([]byte, error)
+	SignText(account common.Address, password string, text []byte) ([]byte, error)
 	UnsetKey(account common.Address, password string)
 	Accounts() []common.Address
+	Wallet(account common.Address) (spec *WalletSpec, ok bool)
+	ChangePassphrase(account common.Address, password, newPassword string, scryptN, scryptP int) error
 	AddPath(keybase string) error
 	RemovePath(keybase string)
 	Paths() []string
 }
 
+// WalletWatcher is implemented by keystores that pick up keyfiles added or removed by other tools.
+type WalletWatcher interface {
+	NewWalletSubscribeNotify(notifyC chan<- *WalletEvent)
+	Watch(interval time.Duration) (stop func())
+}
+
+type WalletEventKind int
+
+const (
+	WalletAdded WalletEventKind = iota
+	WalletRemoved
+)
+
+type WalletEvent struct {
+	Kind   WalletEventKind
+	Wallet *WalletSpec
+}
+
 func New(paths ...string) (EthKeyStore, error) {
 	ks := &keyStore{
 		cache:                      ethfw.NewKeyCache(),
 		notifyWalletSubscribersMux: new(sync.RWMutex),
 		paths:                      make(map[string]struct{}),
 		pathsMux:                   new(sync.RWMutex),
+		wallets:                    make(map[common.Address]*WalletSpec),
+		walletsMux:                 new(sync.Mutex),
+		specs:                      make(map[string]*cachedWalletSpec),
+		specsMux:                   new(sync.Mutex),
 	}
 	for _, path := range paths {
 		ks.paths[path] = struct{}{}
@@ -44,35 +77,116 @@ func New(paths ...string) (EthKeyStore, error) {
 
 type keyStore struct {
 	cache                      ethfw.KeyCache
-	notifyWalletSubscribers    []chan<- *WalletSpec
+	notifyWalletSubscribers    []chan<- *WalletEvent
 	notifyWalletSubscribersMux *sync.RWMutex
 
 	paths    map[string]struct{}
 	pathsMux *sync.RWMutex
+
+	// wallets found by the last paths check
+	wallets    map[common.Address]*WalletSpec
+	walletsMux *sync.Mutex
+
+	// parsed keyfiles by path, re-read only when changed on disk
+	specs    map[string]*cachedWalletSpec
+	specsMux *sync.Mutex
+}
+
+type cachedWalletSpec struct {
+	modTime time.Time
+	size    int64
+	spec    *WalletSpec
+	err     error
 }
 
 func (ks *keyStore) checkPaths() {
-	paths := ks.Paths()
-	for _, keybasePath := range paths {
-		err := ks.forEachWallet(keybasePath, func(spec *WalletSpec) error {
-			if isNew := ks.cache.SetPath(spec.HexToAddress(), spec.Path); isNew {
-				subs := ks.getNotifyWalletSubscribers()
-				for _, notifyC := range subs {
-					select {
-					case notifyC <- spec:
-					default:
-					}
-				}
-			}
+	found := make(map[common.Address]*WalletSpec)
+	failedPaths := make(map[string]bool)
+	visited := make(map[string]bool)
+
+	for _, keybasePath := range ks.Paths() {
+		err := ks.walkWallets(keybasePath, visited, func(spec *WalletSpec) error {
+			found[spec.HexToAddress()] = spec
 			return nil
 		})
 		if err != nil {
+			failedPaths[filepath.Clean(keybasePath)] = true
 			logrus.WithFields(logrus.Fields{
 				"keybasePath": keybasePath,
 				"fn":          "checkPaths",
 			}).WithError(err).Warningln("failed to lookup")
 		}
 	}
+
+	ks.specsMux.Lock()
+	for path := range ks.specs {
+		if !visited[path] && !failedPaths[filepath.Dir(path)] {
+			delete(ks.specs, path)
+		}
+	}
+	ks.specsMux.Unlock()
+
+	var events []*WalletEvent
+
+	ks.walletsMux.Lock()
+	for account, spec := range ks.wallets {
+		if _, ok := found[account]; ok {
+			continue
+		} else if failedPaths[filepath.Dir(spec.Path)] {
+			// the path might be only partially checked, e.g. a keyfile is being written
+			found[account] = spec
+			continue
+		}
+		ks.cache.UnsetPath(account, spec.Path)
+		events = append(events, &WalletEvent{
+			Kind:   WalletRemoved,
+			Wallet: spec,
+		})
+	}
+	for account, spec := range found {
+		ks.cache.SetPath(account, spec.Path)
+		if _, ok := ks.wallets[account]; !ok {
+			events = append(events, &WalletEvent{
+				Kind:   WalletAdded,
+				Wallet: spec,
+			})
+		}
+	}
+	ks.wallets = found
+	ks.walletsMux.Unlock()
+
+	subs := ks.getNotifyWalletSubscribers()
+	for _, ev := range events {
+		for _, notifyC := range subs {
+			select {
+			case notifyC <- ev:
+			default:
+			}
+		}
+	}
+}
+
+// Watch checks keystore paths for added or removed keyfiles periodically, until stopped.
+func (ks *keyStore) Watch(interval time.Duration) (stop func()) {
+	stopC := make(chan struct{})
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stopC:
+				return
+			case <-t.C:
+				ks.checkPaths()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopC)
+		})
+	}
 }
 
 func (ks *keyStore) PrivateKey(account common.Address, password string) (key *ecdsa.PrivateKey, ok bool) {
@@ -83,6 +197,26 @@ func (ks *keyStore) SignerFn(account common.Address, password string) bind.Signe
 	return ks.cache.SignerFn(account, password)
 }
 
+func (ks *keyStore) SignHash(account common.Address, password string, kind HashKind, hash common.Hash) ([]byte, error) {
+	key, ok := ks.cache.PrivateKey(account, password)
+	if !ok {
+		return nil, ethfw.ErrKeyDecrypt
+	}
+	return SignHashWithKey(key, kind, hash)
+}
+
+func (ks *keyStore) SignTypedData(account common.Address, password string, typedData signercore.TypedData) ([]byte, error) {
+	hash, err := TypedDataHash(&typedData)
+	if err != nil {
+		return nil, err
+	}
+	return ks.SignHash(account, password, HashKindTypedData, hash)
+}
+
+func (ks *keyStore) SignText(account common.Address, password string, text []byte) ([]byte, error) {
+	return ks.SignHash(account, password, HashKindText, TextHash(text))
+}
+
 func (ks *keyStore) UnsetKey(account common.Address, password string) {
 	ks.cache.UnsetKey(account, password)
 }
@@ -104,9 +238,93 @@ func (ks *keyStore) Accounts() []common.Address {
 	return accounts
 }
 
+// Wallet finds the keyfile of the account across all keystore paths.
+func (ks *keyStore) Wallet(account common.Address) (spec *WalletSpec, ok bool) {
+	for _, keybasePath := range ks.Paths() {
+		err := ks.forEachWallet(keybasePath, func(walletSpec *WalletSpec) error {
+			if walletSpec.HexToAddress() == account {
+				spec = walletSpec
+				return errRangeStop
+			}
+			return nil
+		})
+		if err == errRangeStop {
+			return spec, true
+		} else if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"keybasePath": keybasePath,
+				"fn":          "Wallet",
+			}).WithError(err).Warningln("failed to lookup")
+		}
+	}
+	return nil, false
+}
+
 var errRangeStop = errors.New("stop")
 
+// ChangePassphrase decrypts the keyfile of the account and re-encrypts it with a new password
+// and scrypt params. The keyfile is replaced atomically, the cached key for the old password is dropped.
+func (ks *keyStore) ChangePassphrase(account common.Address, password, newPassword string, scryptN, scryptP int) error {
+	spec, ok := ks.Wallet(account)
+	if !ok {
+		return ethfw.ErrNoKeyStore
+	}
+	keyJSON, err := ioutil.ReadFile(spec.Path)
+	if err != nil {
+		return err
+	}
+	key, err := ethKeystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return ethfw.ErrKeyDecrypt
+	}
+	newKeyJSON, err := ethKeystore.EncryptKey(key, newPassword, scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(spec.Path, newKeyJSON); err != nil {
+		return err
+	}
+	ks.cache.UnsetKey(account, password)
+	return nil
+}
+
+// writeFileAtomic writes data into a temp file next to the target and renames it over the target,
+// so the target is either left intact or fully replaced.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	} else if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	} else if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
 func (ks *keyStore) forEachWallet(keybasePath string, fn func(spec *WalletSpec) error) error {
+	return ks.walkWallets(keybasePath, nil, fn)
+}
+
+// walkWallets is forEachWallet that also marks every visited keyfile path, if visited is not nil.
+func (ks *keyStore) walkWallets(keybasePath string, visited map[string]bool, fn func(spec *WalletSpec) error) error {
 	return filepath.Walk(keybasePath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -114,23 +332,72 @@ func (ks *keyStore) forEachWallet(keybasePath string, fn func(spec *WalletSpec)
 			return nil
 		} else if info.IsDir() {
 			return filepath.SkipDir
+		} else if isNonKeyFile(info) {
+			return nil
 		}
-		var spec *WalletSpec
-		if data, err := ioutil.ReadFile(path); err != nil {
-			return err
-		} else if err = json.Unmarshal(data, &spec); err != nil {
-			return err
+		if visited != nil {
+			visited[path] = true
 		}
-		if len(spec.Address) == 0 {
-			return fmt.Errorf("failed to load address from %s", path)
-		} else if !common.IsHexAddress(spec.Address) {
-			return fmt.Errorf("wrong (not hex) address from %s", path)
+		spec, err := ks.walletSpec(path, info)
+		if err != nil {
+			// keystore dirs may be shared with other files
+			logrus.WithField("path", path).WithError(err).Debugln("skipping non-keyfile")
+			return nil
 		}
-		spec.Path = path
 		return fn(spec)
 	})
 }
 
+// walletSpec returns a copy of the cached keyfile spec, reading the file only if its size
+// or modification time has changed since the last read.
+func (ks *keyStore) walletSpec(path string, info os.FileInfo) (*WalletSpec, error) {
+	ks.specsMux.Lock()
+	cached, ok := ks.specs[path]
+	ks.specsMux.Unlock()
+
+	if !ok || !cached.modTime.Equal(info.ModTime()) || cached.size != info.Size() {
+		spec, err := readWalletSpec(path)
+		cached = &cachedWalletSpec{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+			spec:    spec,
+			err:     err,
+		}
+
+		ks.specsMux.Lock()
+		ks.specs[path] = cached
+		ks.specsMux.Unlock()
+	}
+
+	if cached.err != nil {
+		return nil, cached.err
+	}
+	spec := *cached.spec
+	return &spec, nil
+}
+
+func readWalletSpec(path string) (*WalletSpec, error) {
+	var spec *WalletSpec
+	if data, err := ioutil.ReadFile(path); err != nil {
+		return nil, err
+	} else if err = json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	if spec == nil || len(spec.Address) == 0 {
+		return nil, fmt.Errorf("failed to load address from %s", path)
+	} else if !common.IsHexAddress(spec.Address) {
+		return nil, fmt.Errorf("wrong (not hex) address from %s", path)
+	}
+	spec.Path = path
+	return spec, nil
+}
+
+// isNonKeyFile skips hidden files, like temporary files being written, and editor backups.
+func isNonKeyFile(info os.FileInfo) bool {
+	name := info.Name()
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") || !info.Mode().IsRegular()
+}
+
 func (ks *keyStore) AddPath(keybase string) error {
 	f, err := os.Stat(keybase)
 	if err != nil {
@@ -141,6 +408,7 @@ func (ks *keyStore) AddPath(keybase string) error {
 	ks.pathsMux.Lock()
 	ks.paths[keybase] = struct{}{}
 	ks.pathsMux.Unlock()
+	ks.checkPaths()
 	return nil
 }
 
@@ -148,6 +416,7 @@ func (ks *keyStore) RemovePath(keybase string) {
 	ks.pathsMux.Lock()
 	delete(ks.paths, keybase)
 	ks.pathsMux.Unlock()
+	ks.checkPaths()
 }
 
 func (ks *keyStore) Paths() []string {
@@ -161,13 +430,13 @@ func (ks *keyStore) Paths() []string {
 	return paths
 }
 
-func (ks *keyStore) NewWalletSubscribeNotify(notifyC chan<- *WalletSpec) {
+func (ks *keyStore) NewWalletSubscribeNotify(notifyC chan<- *WalletEvent) {
 	ks.notifyWalletSubscribersMux.Lock()
 	ks.notifyWalletSubscribers = append(ks.notifyWalletSubscribers, notifyC)
 	ks.notifyWalletSubscribersMux.Unlock()
 }
 
-func (ks *keyStore) getNotifyWalletSubscribers() []chan<- *WalletSpec {
+func (ks *keyStore) getNotifyWalletSubscribers() []chan<- *WalletEvent {
 	ks.notifyWalletSubscribersMux.RLock()
 	subs := ks.notifyWalletSubscribers
 	ks.notifyWalletSubscribersMux.RUnlock()
@@ -184,3 +453,57 @@ type WalletSpec struct {
 func (spec *WalletSpec) HexToAddress() common.Address {
 	return common.HexToAddress(spec.Address)
 }
+
+// HashKind tells what a signed hash represents.
+type HashKind string
+
+const (
+	HashKindOrder     HashKind = "order"
+	HashKindZeroExTx  HashKind = "zeroex_tx"
+	HashKindEthTx     HashKind = "eth_tx"
+	HashKindTypedData HashKind = "typed_data"
+	HashKindText      HashKind = "text"
+)
+
+// IsMessage returns true for hashes that must be signed as eth_sign messages.
+func (k HashKind) IsMessage() bool {
+	return k == HashKindOrder || k == HashKindZeroExTx
+}
+
+func (k HashKind) IsValid() bool {
+	return k.IsMessage() || k == HashKindEthTx || k == HashKindTypedData || k == HashKindText
+}
+
+// SignHashWithKey signs the hash, order and 0x transaction hashes are prefixed as eth_sign messages.
+// The signature is in the [R || S || V] format where V is 0 or 1.
+func SignHashWithKey(key *ecdsa.PrivateKey, kind HashKind, hash common.Hash) ([]byte, error) {
+	if !kind.IsValid() {
+		return nil, fmt.Errorf("unsupported hash kind: %s", kind)
+	}
+	digest := hash.Bytes()
+	if kind.IsMessage() {
+		digest = accounts.TextHash(digest)
+	}
+	return crypto.Sign(digest, key)
+}
+
+// TextHash computes the personal_sign hash of an arbitrary message:
+// keccak256("\x19Ethereum Signed Message:\n" ‖ len(text) ‖ text)
+func TextHash(text []byte) common.Hash {
+	return common.BytesToHash(accounts.TextHash(text))
+}
+
+// TypedDataHash computes the EIP-712 hash to sign:
+// keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func TypedDataHash(typedData *signercore.TypedData) (common.Hash, error) {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	rawData := []byte(fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(typedDataHash)))
+	return crypto.Keccak256Hash(rawData), nil
+}
@@ -0,0 +1,132 @@
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethKeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestChangePassphrase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	acc, err := ethKeystore.StoreKey(dir, "12345678", ethKeystore.LightScryptN, ethKeystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ks.PrivateKey(acc.Address, "12345678"); !ok {
+		t.Fatal("failed to decrypt with the old password")
+	}
+
+	if err := ks.ChangePassphrase(acc.Address, "wrongpass", "newpassword", ethKeystore.LightScryptN, ethKeystore.LightScryptP); err == nil {
+		t.Fatal("expected error for a wrong password")
+	}
+	if err := ks.ChangePassphrase(acc.Address, "12345678", "newpassword", ethKeystore.LightScryptN, ethKeystore.LightScryptP); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ks.PrivateKey(acc.Address, "12345678"); ok {
+		t.Fatal("old password must not unlock the key anymore")
+	}
+	if _, ok := ks.PrivateKey(acc.Address, "newpassword"); !ok {
+		t.Fatal("failed to decrypt with the new password")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(files) != 1 {
+		t.Fatalf("expected a single keyfile, got %d files", len(files))
+	}
+}
+
+func TestSignText(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	acc, err := ethKeystore.StoreKey(dir, "12345678", ethKeystore.LightScryptN, ethKeystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := []byte("I own this address")
+	sig, err := ks.SignText(acc.Address, "12345678", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(text), sig)
+	if err != nil {
+		t.Fatal(err)
+	} else if crypto.PubkeyToAddress(*pub) != acc.Address {
+		t.Fatal("text signed by a wrong key")
+	}
+
+	if _, err := ks.SignText(acc.Address, "wrongpass", text); err == nil {
+		t.Fatal("expected error for a wrong password")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher := ks.(WalletWatcher)
+	eventsC := make(chan *WalletEvent, 1)
+	watcher.NewWalletSubscribeNotify(eventsC)
+	stop := watcher.Watch(10 * time.Millisecond)
+	defer stop()
+
+	acc, err := ethKeystore.StoreKey(dir, "12345678", ethKeystore.LightScryptN, ethKeystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-eventsC:
+		if ev.Kind != WalletAdded || ev.Wallet.HexToAddress() != acc.Address {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("keyfile added to keystore dir has not been noticed")
+	}
+	if _, ok := ks.PrivateKey(acc.Address, "12345678"); !ok {
+		t.Fatal("failed to decrypt the added keyfile")
+	}
+
+	if err := os.Remove(acc.URL.Path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-eventsC:
+		if ev.Kind != WalletRemoved || ev.Wallet.HexToAddress() != acc.Address {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("keyfile removed from keystore dir has not been noticed")
+	}
+}
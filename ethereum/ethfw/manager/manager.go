@@ -40,6 +40,8 @@ type EthManager interface {
 	TransactionByHash(ctx context.Context, txHex string) (*TxInfo, error)
 	TransactionReceiptByHash(ctx context.Context, txHex string) (*TxReceipt, error)
 	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BatchCallContract(ctx context.Context, calls []ethereum.CallMsg) ([][]byte, []error, error)
 
 	ChainID() uint64
 	GasLimit() uint64
@@ -139,6 +141,59 @@ func (m *ethManager) FilterLogs(ctx context.Context, query ethereum.FilterQuery)
 	return cli.FilterLogs(ctx, query)
 }
 
+// BatchCallContract executes calls at the latest block in a single RPC batch. The returned error
+// is set when the batch as a whole failed, errors of individual calls are returned per call.
+func (m *ethManager) BatchCallContract(ctx context.Context, calls []ethereum.CallMsg) ([][]byte, []error, error) {
+	ctx, cancelFn := contextWithCloseChan(ctx, m.closeC)
+	defer cancelFn()
+	cli, _, ok := m.rpcClient(ctx)
+	if !ok {
+		return nil, nil, errNodeUnavailable
+	}
+
+	results := make([]hexutil.Bytes, len(calls))
+	batch := make([]rpc.BatchElem, len(calls))
+	for idx, call := range calls {
+		arg := map[string]interface{}{
+			"from": call.From,
+			"to":   call.To,
+		}
+		if len(call.Data) > 0 {
+			arg["data"] = hexutil.Bytes(call.Data)
+		}
+
+		batch[idx] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []interface{}{arg, "latest"},
+			Result: &results[idx],
+		}
+	}
+
+	if err := cli.BatchCallContext(ctx, batch); err != nil {
+		return nil, nil, err
+	}
+
+	out := make([][]byte, len(calls))
+	errs := make([]error, len(calls))
+	for idx := range batch {
+		out[idx] = results[idx]
+		errs[idx] = batch[idx].Error
+	}
+
+	return out, errs, nil
+}
+
+func (m *ethManager) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	ctx, cancelFn := contextWithCloseChan(ctx, m.closeC)
+	defer cancelFn()
+	rpc, _, ok := m.rpcClient(ctx)
+	if !ok {
+		return nil, errNodeUnavailable
+	}
+	cli := ethclient.NewClient(rpc)
+	return cli.HeaderByNumber(ctx, number)
+}
+
 func (m *ethManager) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
 	ctx, cancelFn := contextWithCloseChan(ctx, m.closeC)
 	defer cancelFn()
@@ -19,6 +19,7 @@ require (
 	github.com/sirupsen/logrus v1.5.0
 	github.com/stretchr/testify v1.5.1
 	github.com/tj/go-spin v1.1.0
+	github.com/tyler-smith/go-bip39 v1.0.2
 	github.com/xlab/closer v0.0.0-20190328110542-03326addb7c2
 	github.com/xlab/structwalk v1.1.1
 	github.com/xlab/termtables v1.0.0
@@ -33,6 +33,7 @@ func main() {
 	}
 
 	app.Command("v version", "Print application version", versionCmd)
+	app.Command("agent", "Run a key agent that holds unlocked keys and signs over a Unix socket", agentCmd)
 
 	if err := app.Run(os.Args); err != nil {
 		logrus.Fatalln(err)
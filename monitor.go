@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/xlab/termtables"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethcore"
+	restAPI "github.com/InjectiveLabs/dexterm/gen/rest_api"
+)
+
+// RiskMonitor periodically checks open futures positions of an account
+// and raises alerts when they come close to liquidation.
+type RiskMonitor struct {
+	ctl *AppController
+
+	mux          *sync.RWMutex
+	cancelFn     context.CancelFunc
+	account      common.Address
+	signPassword string
+	settings     *monitorSettings
+	alert        string
+	atRisk       string
+	lastErr      string
+
+	// reduce txs not mined yet, by position ID
+	pendingReduces map[string]common.Hash
+}
+
+func NewRiskMonitor(ctl *AppController) *RiskMonitor {
+	return &RiskMonitor{
+		ctl:            ctl,
+		mux:            new(sync.RWMutex),
+		pendingReduces: make(map[string]common.Hash),
+	}
+}
+
+// monitorSettings are thresholds of the monitor.* config section.
+type monitorSettings struct {
+	Interval          time.Duration
+	MarginRatioAlert  decimal.Decimal
+	DistanceAlert     decimal.Decimal
+	AutoReduce        bool
+	AutoReduceRatio   decimal.Decimal
+	AutoReducePercent decimal.Decimal
+}
+
+// loadMonitorSettings parses and validates monitor thresholds from the config.
+func (ctl *AppController) loadMonitorSettings() (*monitorSettings, error) {
+	settings := &monitorSettings{
+		AutoReduce: toBool(ctl.mustConfigValue("monitor.auto_reduce")),
+	}
+
+	var err error
+	if settings.Interval, err = time.ParseDuration(ctl.mustConfigValue("monitor.interval")); err != nil {
+		err = errors.Wrap(err, "failed to parse monitor.interval")
+		return nil, err
+	} else if settings.Interval <= 0 {
+		return nil, errors.New("monitor.interval must be positive")
+	}
+
+	decimals := []struct {
+		Path  string
+		Value *decimal.Decimal
+	}{
+		{"monitor.margin_ratio_alert", &settings.MarginRatioAlert},
+		{"monitor.liquidation_distance_alert", &settings.DistanceAlert},
+		{"monitor.auto_reduce_ratio", &settings.AutoReduceRatio},
+		{"monitor.auto_reduce_percent", &settings.AutoReducePercent},
+	}
+
+	for _, d := range decimals {
+		v, err := decimal.NewFromString(ctl.mustConfigValue(d.Path))
+		if err != nil {
+			err = errors.Wrapf(err, "failed to parse %s", d.Path)
+			return nil, err
+		} else if v.IsNegative() {
+			return nil, errors.Errorf("%s must not be negative", d.Path)
+		}
+
+		*d.Value = v
+	}
+
+	if settings.AutoReducePercent.IsZero() || settings.AutoReducePercent.GreaterThan(decimal.NewFromInt(100)) {
+		return nil, errors.New("monitor.auto_reduce_percent must be within (0, 100]")
+	}
+
+	return settings, nil
+}
+
+var ErrMonitorRunning = errors.New("risk monitor is already running")
+
+// Start runs the monitor of the account positions in background. The password is used
+// for auto-reduce orders only, auto-reduce is not possible if it's empty.
+func (m *RiskMonitor) Start(account common.Address, signPassword string) error {
+	settings, err := m.ctl.loadMonitorSettings()
+	if err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.cancelFn != nil {
+		return ErrMonitorRunning
+	}
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	m.cancelFn = cancelFn
+	m.account = account
+	m.signPassword = signPassword
+	m.settings = settings
+
+	go m.run(ctx, settings.Interval)
+
+	return nil
+}
+
+// Account returns the account being monitored, if the monitor is running.
+func (m *RiskMonitor) Account() (common.Address, bool) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	return m.account, m.cancelFn != nil
+}
+
+func (m *RiskMonitor) Stop() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.cancelFn != nil {
+		m.cancelFn()
+	}
+
+	m.cancelFn = nil
+	m.account = common.Address{}
+	m.signPassword = ""
+	m.settings = nil
+	m.alert = ""
+	m.atRisk = ""
+	m.lastErr = ""
+	m.pendingReduces = make(map[string]common.Hash)
+}
+
+func (m *RiskMonitor) IsRunning() bool {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	return m.cancelFn != nil
+}
+
+// Alert returns a short description of positions at risk, empty if there are none.
+func (m *RiskMonitor) Alert() string {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	return m.alert
+}
+
+func (m *RiskMonitor) run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	checkCtx, cancelFn := context.WithTimeout(ctx, interval)
+	m.check(checkCtx)
+	cancelFn()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			checkCtx, cancelFn := context.WithTimeout(ctx, interval)
+			m.check(checkCtx)
+			cancelFn()
+		}
+	}
+}
+
+func (m *RiskMonitor) check(ctx context.Context) {
+	m.mux.RLock()
+	account := m.account
+	settings := m.settings
+	signPassword := m.signPassword
+	m.mux.RUnlock()
+
+	if settings == nil {
+		// stopped meanwhile
+		return
+	}
+
+	risks, err := m.ctl.getPositionRisks(ctx, account)
+	if err != nil {
+		m.warnOnce(err, "risk monitor: unable to check positions")
+		return
+	}
+
+	var alerts, atRisk []string
+	for _, risk := range risks {
+		if risk.MarginRatio.GreaterThanOrEqual(settings.MarginRatioAlert) &&
+			risk.Distance.GreaterThanOrEqual(settings.DistanceAlert) {
+			continue
+		}
+
+		alerts = append(alerts, fmt.Sprintf("%s %sx %s%%",
+			risk.Market, risk.MarginRatio.StringFixed(2), risk.Distance.StringFixed(1)))
+		atRisk = append(atRisk, risk.Position.ID.String())
+
+		// without a passphrase there is nothing to sign with, that's reported on start
+		if settings.AutoReduce && len(signPassword) > 0 && risk.MarginRatio.LessThan(settings.AutoReduceRatio) {
+			if err := m.reduce(account, signPassword, settings.AutoReducePercent, risk); err != nil {
+				m.warnOnce(err, fmt.Sprintf("risk monitor: unable to reduce position %s in %s", risk.Position.ID.String(), risk.Market))
+			}
+		}
+	}
+
+	alert := strings.Join(alerts, ", ")
+	atRiskIDs := strings.Join(atRisk, ",")
+
+	m.mux.Lock()
+	prevAtRiskIDs := m.atRisk
+	m.alert = alert
+	m.atRisk = atRiskIDs
+	m.mux.Unlock()
+
+	// figures in the alert change every check, only a change of positions at risk is reported
+	if atRiskIDs == prevAtRiskIDs {
+		return
+	} else if len(alert) == 0 {
+		logrus.Infoln("risk monitor: no positions at risk anymore")
+		return
+	}
+
+	// the alert is shown in the prompt prefix, ring the terminal bell as well
+	logrus.Warningln("risk monitor: positions at risk of liquidation:", alert)
+	fmt.Print("\a")
+}
+
+// warnOnce logs the error unless it's the same as the last one, so a persistent
+// failure doesn't flood the terminal on every check.
+func (m *RiskMonitor) warnOnce(err error, msg string) {
+	m.mux.Lock()
+	repeated := m.lastErr == msg+err.Error()
+	m.lastErr = msg + err.Error()
+	m.mux.Unlock()
+
+	if !repeated {
+		logrus.WithError(err).Warningln(msg)
+	}
+}
+
+// reduce partially closes the position against the best order. The position is skipped
+// while the previous reduce tx is pending, so it's never closed twice over.
+func (m *RiskMonitor) reduce(
+	account common.Address,
+	signPassword string,
+	percent decimal.Decimal,
+	risk *positionRisk,
+) error {
+	positionID := risk.Position.ID.String()
+
+	m.mux.RLock()
+	_, pending := m.pendingReduces[positionID]
+	m.mux.RUnlock()
+
+	if pending {
+		return nil
+	}
+
+	// sending must not depend on the check interval
+	ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelFn()
+
+	quantity := risk.Quantity.Mul(percent).Div(decimal.NewFromInt(100)).Ceil()
+	if quantity.GreaterThan(risk.Quantity) {
+		quantity = risk.Quantity
+	}
+
+	record, err := m.ctl.bestCloseOrder(ctx, risk.Market, risk.Position.IsLong(), account)
+	if err != nil {
+		err = errors.Wrap(err, "unable to find an order to reduce position")
+		return err
+	}
+
+	fillable, err := decimal.NewFromString(record.MetaData["remainingTakerAssetAmount"])
+	if err != nil {
+		err = errors.Wrap(err, "unable to get remaining quantity of the order")
+		return err
+	} else if quantity.GreaterThan(fillable) {
+		quantity = fillable
+	}
+
+	callArgs := &ethcore.CallArgs{
+		Context:  ctx,
+		From:     account,
+		FromPass: signPassword,
+		GasPrice: m.ctl.ethGasPrice,
+	}
+
+	order, signature := do2wo(record.DerivativeOrder)
+	closeQuantity, _ := big.NewInt(0).SetString(quantity.String(), 10)
+
+	txHash, err := m.ctl.ethCore.ClosePosition(callArgs, risk.Position.ID, order, signature, closeQuantity)
+	if err != nil {
+		return err
+	}
+
+	m.mux.Lock()
+	m.pendingReduces[positionID] = txHash
+	m.mux.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"market":   risk.Market,
+		"position": positionID,
+		"quantity": quantity.String(),
+	}).Warningln("risk monitor: reduce tx sent", m.ctl.formatTxLink(txHash))
+
+	go m.awaitReduce(positionID, txHash)
+
+	return nil
+}
+
+func (m *RiskMonitor) awaitReduce(positionID string, txHash common.Hash) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancelFn()
+
+	err := m.ctl.awaitTx(ctx, txHash)
+
+	m.mux.Lock()
+	if m.pendingReduces[positionID] == txHash {
+		delete(m.pendingReduces, positionID)
+	}
+	m.mux.Unlock()
+
+	if err != nil {
+		logrus.WithField("position", positionID).WithError(err).
+			Errorln("risk monitor: reduce tx failed", m.ctl.formatTxLink(txHash))
+		return
+	}
+
+	logrus.WithField("position", positionID).Infoln("risk monitor: reduce tx confirmed", m.ctl.formatTxLink(txHash))
+}
+
+// positionRisk describes how close a futures position is to liquidation.
+type positionRisk struct {
+	Market   string
+	Position *ethcore.Position
+
+	OraclePrice      decimal.Decimal
+	EntryPrice       decimal.Decimal
+	Quantity         decimal.Decimal
+	Margin           decimal.Decimal
+	UnrealizedPnL    decimal.Decimal
+	MinMargin        decimal.Decimal
+	MarginRatio      decimal.Decimal
+	LiquidationPrice decimal.Decimal
+	Distance         decimal.Decimal
+}
+
+// getPositionRisks evaluates all open positions of the account against current oracle prices.
+func (ctl *AppController) getPositionRisks(ctx context.Context, account common.Address) ([]*positionRisk, error) {
+	markets, err := ctl.restClient.DerivativeMarkets(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "failed to fetch derivatives markets")
+		return nil, err
+	}
+
+	var risks []*positionRisk
+	for _, market := range markets {
+		if !market.Enabled {
+			continue
+		}
+
+		// a failing market must not hide risks of the others
+		marketRisks, err := ctl.getMarketPositionRisks(ctx, account, market)
+		if err != nil {
+			logrus.WithError(err).WithField("market", market.Ticker).Warningln("unable to check positions of the market")
+			continue
+		}
+
+		risks = append(risks, marketRisks...)
+	}
+
+	return risks, nil
+}
+
+func (ctl *AppController) getMarketPositionRisks(
+	ctx context.Context,
+	account common.Address,
+	market *restAPI.DerivativeMarket,
+) ([]*positionRisk, error) {
+	marketID := common.HexToHash(market.MarketID)
+	positions, err := ctl.ethCore.GetPositionsForTrader(ctx, account, marketID)
+	if err != nil {
+		return nil, err
+	} else if len(positions) == 0 {
+		return nil, nil
+	}
+
+	oraclePrice, err := ctl.getOraclePrice(ctx, market)
+	if err != nil {
+		err = errors.Wrap(err, "failed to get oracle price")
+		return nil, err
+	}
+
+	risks := make([]*positionRisk, 0, len(positions))
+	for _, position := range positions {
+		minMargin, err := ctl.ethCore.CalcMinMargin(ctx, marketID, position.Quantity, oraclePrice)
+		if err != nil {
+			return nil, err
+		}
+
+		risks = append(risks, calcPositionRisk(market.Ticker, position, oraclePrice, minMargin))
+	}
+
+	return risks, nil
+}
+
+func calcPositionRisk(market string, position *ethcore.Position, oraclePrice, minMargin *big.Int) *positionRisk {
+	risk := &positionRisk{
+		Market:      market,
+		Position:    position,
+		OraclePrice: decimal.NewFromBigInt(oraclePrice, -18),
+		EntryPrice:  decimal.NewFromBigInt(position.ContractPrice, -18),
+		Quantity:    decimal.NewFromBigInt(position.Quantity, 0),
+		Margin:      decimal.NewFromBigInt(position.Margin, -18),
+		MinMargin:   decimal.NewFromBigInt(minMargin, -18),
+	}
+
+	risk.UnrealizedPnL = risk.OraclePrice.Sub(risk.EntryPrice).Mul(risk.Quantity)
+	if !position.IsLong() {
+		risk.UnrealizedPnL = risk.UnrealizedPnL.Neg()
+	}
+
+	if risk.MinMargin.IsPositive() {
+		risk.MarginRatio = risk.Margin.Add(risk.UnrealizedPnL).Div(risk.MinMargin)
+	}
+
+	risk.LiquidationPrice = calcLiquidationPrice(position.IsLong(), risk.EntryPrice, risk.Quantity, risk.Margin, risk.MinMargin)
+	if risk.OraclePrice.IsPositive() {
+		risk.Distance = risk.OraclePrice.Sub(risk.LiquidationPrice).Abs().Div(risk.OraclePrice).Mul(decimal.NewFromInt(100))
+	}
+
+	return risk
+}
+
+func printPositionRisks(risks []*positionRisk) {
+	table := termtables.CreateTable()
+	table.UTF8Box()
+	table.AddTitle("POSITIONS")
+	table.AddHeaders(
+		"Market",
+		"ID",
+		"Side",
+		"Contracts",
+		"Entry",
+		"Oracle",
+		"PnL",
+		"Margin Ratio",
+		"Liquidation",
+		"Distance",
+	)
+
+	if len(risks) == 0 {
+		table.AddRow("No open positions.", "", "", "", "", "", "", "", "", "")
+	}
+
+	for _, risk := range risks {
+		side := color.GreenString("LONG")
+		if !risk.Position.IsLong() {
+			side = color.RedString("SHORT")
+		}
+
+		pnl := color.GreenString(risk.UnrealizedPnL.StringFixed(9))
+		if risk.UnrealizedPnL.IsNegative() {
+			pnl = color.RedString(risk.UnrealizedPnL.StringFixed(9))
+		}
+
+		table.AddRow(
+			risk.Market,
+			risk.Position.ID.String(),
+			side,
+			risk.Quantity.String(),
+			risk.EntryPrice.StringFixed(9),
+			risk.OraclePrice.StringFixed(9),
+			pnl,
+			risk.MarginRatio.StringFixed(2)+"x",
+			risk.LiquidationPrice.StringFixed(9),
+			risk.Distance.StringFixed(2)+"%",
+		)
+	}
+
+	fmt.Println(table.Render())
+}
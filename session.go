@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
+)
+
+// maxSessionDuration bounds how long a signing session may keep the key unlocked.
+const maxSessionDuration = 12 * time.Hour
+
+var (
+	ErrSessionDuration = errors.New("session duration must be positive and not longer than 12h")
+	ErrSessionDecrypt  = errors.New("failed to decrypt keyfile, check the passphrase")
+)
+
+// SigningSession keeps the passphrase of a single account in memory for a bounded time,
+// so the decrypted key stays available in the keystore cache without re-entering the passphrase.
+type SigningSession struct {
+	ks keystore.EthKeyStore
+
+	mux       *sync.RWMutex
+	account   common.Address
+	password  string
+	expiresAt time.Time
+	timer     *time.Timer
+}
+
+func NewSigningSession(ks keystore.EthKeyStore) *SigningSession {
+	return &SigningSession{
+		ks:  ks,
+		mux: new(sync.RWMutex),
+	}
+}
+
+// Unlock decrypts the account key and keeps it available until the duration passes.
+// A previous session is locked first.
+func (s *SigningSession) Unlock(account common.Address, password string, duration time.Duration) error {
+	if duration <= 0 || duration > maxSessionDuration {
+		return ErrSessionDuration
+	}
+
+	s.Lock()
+
+	if _, ok := s.ks.PrivateKey(account, password); !ok {
+		return ErrSessionDecrypt
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.account = account
+	s.password = password
+	s.expiresAt = time.Now().Add(duration)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		s.mux.Lock()
+		defer s.mux.Unlock()
+
+		// the session might have been locked or replaced already
+		if s.timer != timer {
+			return
+		}
+
+		s.lock()
+		logrus.WithField("account", account.Hex()).Infoln("Signing session expired, account is locked")
+	})
+	s.timer = timer
+
+	return nil
+}
+
+// Lock wipes the cached key and the passphrase. Returns false if there was no active session.
+func (s *SigningSession) Lock() bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.timer == nil {
+		return false
+	}
+
+	s.lock()
+
+	return true
+}
+
+func (s *SigningSession) lock() {
+	s.timer.Stop()
+	s.ks.UnsetKey(s.account, s.password)
+
+	s.timer = nil
+	s.account = common.Address{}
+	s.password = ""
+	s.expiresAt = time.Time{}
+}
+
+// Password returns the session passphrase if the account is currently unlocked.
+func (s *SigningSession) Password(account common.Address) (string, bool) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	if s.timer == nil || s.account != account || time.Now().After(s.expiresAt) {
+		return "", false
+	}
+
+	return s.password, true
+}
+
+// Remaining returns the time left until the session expires, zero if locked.
+func (s *SigningSession) Remaining() time.Duration {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	if s.timer == nil {
+		return 0
+	}
+
+	if remaining := time.Until(s.expiresAt); remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	prompt "github.com/c-bata/go-prompt"
 	"github.com/pkg/errors"
@@ -59,13 +60,29 @@ const (
 	MenuTradeDerivativesLimitLong  MenuItem = "limitlong"
 	MenuTradeDerivativesLimitShort MenuItem = "limitshort"
 	MenuTradeDerivativesOrderbook  MenuItem = "orderbook"
+	MenuTradeDerivativesFillOrder  MenuItem = "fill"
+	MenuTradeDerivativesCancel     MenuItem = "cancel"
+	MenuTradeDerivativesCancelAll  MenuItem = "cancelall"
+	MenuTradeDerivativesDeposit    MenuItem = "deposit"
+	MenuTradeDerivativesWithdraw   MenuItem = "withdraw"
+	MenuTradeDerivativesMarkets    MenuItem = "markets"
+	MenuTradeDerivativesMonitor    MenuItem = "monitor"
+	MenuTradeDerivativesUnmonitor  MenuItem = "unmonitor"
+	MenuTradeDerivativesClose      MenuItem = "close"
 
 	// Util menu items
-	MenuUtilUnlock MenuItem = "unlock"
-	MenuUtilLock   MenuItem = "lock"
-	MenuUtilWrap   MenuItem = "wrap"
-	MenuUtilUnwrap MenuItem = "unwrap"
-	MenuUtilTokens MenuItem = "tokens"
+	MenuUtilUnlock     MenuItem = "unlock"
+	MenuUtilLock       MenuItem = "lock"
+	MenuUtilWrap       MenuItem = "wrap"
+	MenuUtilUnwrap     MenuItem = "unwrap"
+	MenuUtilTokens     MenuItem = "tokens"
+	MenuUtilSend       MenuItem = "send"
+	MenuUtilTransfer   MenuItem = "transfer"
+	MenuUtilAllowances MenuItem = "allowances"
+	MenuUtilAllowance  MenuItem = "allowance"
+	MenuUtilRevoke     MenuItem = "revoke"
+	MenuUtilApprovals  MenuItem = "approvals"
+	MenuUtilRevokeAll  MenuItem = "revokeall"
 
 	// Accounts menu items
 	MenuAccountsUse           MenuItem = "use"
@@ -73,6 +90,21 @@ const (
 	MenuAccountsImport        MenuItem = "import"
 	MenuAccountsImportPrivKey MenuItem = "privkey"
 	MenuAccountsList          MenuItem = "list"
+	MenuAccountsExport        MenuItem = "export"
+	MenuAccountsPasswd        MenuItem = "passwd"
+	MenuAccountsMnemonic      MenuItem = "mnemonic"
+	MenuAccountsHDImport      MenuItem = "hdimport"
+	MenuAccountsLabel         MenuItem = "label"
+	MenuAccountsSet           MenuItem = "set"
+	MenuAccountsUnlock        MenuItem = "unlock"
+	MenuAccountsLock          MenuItem = "lock"
+	MenuAccountsWatch         MenuItem = "watch"
+	MenuAccountsUnwatch       MenuItem = "unwatch"
+	MenuAccountsSign          MenuItem = "sign"
+	MenuAccountsVerify        MenuItem = "verify"
+	MenuAccountsAddPath       MenuItem = "addpath"
+	MenuAccountsRemovePath    MenuItem = "rmpath"
+	MenuAccountsActivity      MenuItem = "activity"
 
 	// TODO: move to debug menu
 	// MenuDebugSpotGenerateLimits MenuItem = "generatelimits"
@@ -110,8 +142,18 @@ var tradeSpotSuggestions = []prompt.Suggest{
 var tradeDerivativesSuggestions = []prompt.Suggest{
 	{Text: "l/limitlong", Description: "Create a Limit Long order."},
 	{Text: "h/limitshort", Description: "Create a Limit Short order."},
+	{Text: "f/fill", Description: "Fill an order (Take Order)."},
+	{Text: "c/cancel", Description: "Cancel an order."},
+	{Text: "ca/cancelall", Description: "Cancel all own orders of a market."},
+	{Text: "x/close", Description: "Close a position at market or limit price."},
+
+	{Text: "dp/deposit", Description: "Deposit base currency as collateral into the Futures contract."},
+	{Text: "w/withdraw", Description: "Withdraw free collateral from the Futures contract."},
 
 	{Text: "o/orderbook", Description: "View orderbook of a derivatives market."},
+	{Text: "m/markets", Description: "View derivatives markets with oracle prices."},
+	{Text: "mon/monitor", Description: "Start monitoring liquidation risk of open positions."},
+	{Text: "umon/unmonitor", Description: "Stop monitoring liquidation risk."},
 	{Text: "q/quit", Description: "Quit from the trading menu."},
 }
 
@@ -120,7 +162,22 @@ var accountsSuggestions = []prompt.Suggest{
 	{Text: "c/create", Description: "Create a new account and generate a private key."},
 	{Text: "i/import", Description: "Import an external keyfile into keystore."},
 	{Text: "p/privkey", Description: "Import a private key into keystore."},
-	{Text: "l/list", Description: "List all accounts in keystore."},
+	{Text: "m/mnemonic", Description: "Generate a new BIP39 mnemonic and create its first account."},
+	{Text: "h/hdimport", Description: "Import accounts derived from a BIP39 mnemonic."},
+	{Text: "l/list", Description: "List all accounts, grouped by keystore dir."},
+	{Text: "e/export", Description: "Export an encrypted keyfile or reveal the private key."},
+	{Text: "pw/passwd", Description: "Change passphrase of an account keyfile."},
+	{Text: "lb/label", Description: "Set a label for an account, empty label removes it."},
+	{Text: "s/set", Description: "Set per-account defaults, such as network or gas price."},
+	{Text: "ul/unlock", Description: "Unlock the default account for signing without a passphrase for a while."},
+	{Text: "lk/lock", Description: "Lock the default account and wipe its cached key."},
+	{Text: "w/watch", Description: "Add a watch-only account by address, without a key."},
+	{Text: "uw/unwatch", Description: "Remove a watch-only account."},
+	{Text: "sg/sign", Description: "Sign a message, EIP-712 typed data or an order with the default account."},
+	{Text: "v/verify", Description: "Verify a signature of a message, EIP-712 typed data or an order."},
+	{Text: "ap/addpath", Description: "Add an extra keystore dir, e.g. a shared team keystore."},
+	{Text: "rp/rmpath", Description: "Remove an extra keystore dir, keyfiles are left in place."},
+	{Text: "ac/activity", Description: "View on-chain activity of an account: fills, transfers, wraps and approvals."},
 	{Text: "q/quit", Description: "Quit from the accounts menu."},
 }
 
@@ -130,14 +187,60 @@ var utilSuggestions = []prompt.Suggest{
 	{Text: "l/lock", Description: "Lock a token from trade. Soft cancels all sell orders too."},
 	{Text: "w/wrap", Description: "Wrap ETH into WETH ERC20 tokens."},
 	{Text: "uw/unwrap", Description: "Unwrap WETH ERC20 tokens and receive ETH."},
+	{Text: "s/send", Description: "Send ETH to another account or address."},
+	{Text: "tr/transfer", Description: "Transfer ERC20 tokens to another account or address."},
+	{Text: "al/allowances", Description: "View exact token allowances to ERC20Proxy or another spender."},
+	{Text: "a/allowance", Description: "Set an exact token allowance to ERC20Proxy or another spender."},
+	{Text: "rv/revoke", Description: "Revoke a token allowance to ERC20Proxy or another spender."},
+	{Text: "ap/approvals", Description: "Audit approvals of your account to all spenders, using chain logs."},
+	{Text: "ra/revokeall", Description: "Revoke all approvals found by the audit, or only those to a spender."},
 	{Text: "q/quit", Description: "Quit from the util menu."},
 }
 
+var customTokensSuggestions = []prompt.Suggest{{
+	Text:        "0x0000000000000000000000000000000000000000",
+	Description: "Addresses of custom tokens to scan along with the known ones, comma separated. Can be empty.",
+}}
+
+var approvalBlocksSuggestions = []prompt.Suggest{{
+	Text:        "1000000",
+	Description: "Number of recent blocks to scan, used if left empty. Maximum value is 3000000",
+}}
+
 // var debugSuggestions = []prompt.Suggest{
 // 	{Text: "g/generatelimits", Description: "Generate many limit buy and sell orders to populate the orderbook"},
 // }
 
 func (a *AppState) LivePrefix() func() (prefix string, useLivePrefix bool) {
+	livePrefix := a.livePrefix()
+
+	return func() (prefix string, useLivePrefix bool) {
+		prefix, useLivePrefix = livePrefix()
+
+		if remaining := a.controller.SessionRemaining(); remaining > 0 {
+			if !useLivePrefix {
+				prefix = "∆ "
+			}
+
+			prefix = fmt.Sprintf("🔓 %s %s", remaining.Round(time.Second), prefix)
+			useLivePrefix = true
+		} else if useLivePrefix && a.argContainer == nil && a.controller.DefaultAccountWatchOnly() {
+			prefix = "👁 " + prefix
+		}
+
+		if alert := a.controller.RiskAlert(); len(alert) > 0 {
+			if !useLivePrefix {
+				prefix = "∆ "
+			}
+
+			return fmt.Sprintf("⚠ %s ⚠ %s", alert, prefix), true
+		}
+
+		return prefix, useLivePrefix
+	}
+}
+
+func (a *AppState) livePrefix() func() (prefix string, useLivePrefix bool) {
 	return func() (prefix string, useLivePrefix bool) {
 		if a.argContainer != nil {
 			idx, name := a.argContainer.CurrentField()
@@ -152,6 +255,10 @@ func (a *AppState) LivePrefix() func() (prefix string, useLivePrefix bool) {
 		case MenuMain, MenuAbout:
 			return "", false
 		default:
+			if label := a.controller.DefaultAccountLabel(); len(label) > 0 {
+				return fmt.Sprintf("%s@%s ∆ ", label, a.root), true
+			}
+
 			return string(a.root) + " ∆ ", true
 		}
 	}
@@ -161,7 +268,17 @@ func (a *AppState) Completer() prompt.Completer {
 	return func(d prompt.Document) []prompt.Suggest {
 		switch {
 		case a.argContainer != nil:
-			if a.isCurrentFieldPassword() {
+			if _, ok := a.sessionSignPassword(); ok {
+				return []prompt.Suggest{{
+					Text:        "Unlocked",
+					Description: "Account is unlocked for a signing session, press Enter to sign.",
+				}}
+			} else if a.isWatchOnlySign() {
+				return []prompt.Suggest{{
+					Text:        "Watch-only",
+					Description: "Account has no key and can't sign, press Enter to continue.",
+				}}
+			} else if a.isCurrentFieldPassword() {
 				return []prompt.Suggest{{
 					Text:        "Passphrase",
 					Description: "Sign using a private key, need to provide a passphrase to unlock it.",
@@ -183,6 +300,8 @@ func (a *AppState) Completer() prompt.Completer {
 
 func (a *AppState) Executor() prompt.Executor {
 	return func(cmd string) {
+		a.controller.applyKeystoreUpdates()
+
 		switch a.root {
 		case MenuMain:
 			if isEmpty(cmd) {
@@ -209,7 +328,28 @@ func (a *AppState) Controller() *AppController {
 
 func (a *AppState) isCurrentFieldPassword() bool {
 	_, fieldName := a.argContainer.CurrentField()
-	return strings.Contains(strings.ToLower(fieldName), "password")
+	fieldName = strings.ToLower(fieldName)
+	return strings.Contains(fieldName, "password") || strings.Contains(fieldName, "mnemonic")
+}
+
+// sessionSignPassword returns the session passphrase if the current field is a sign passphrase
+// and the default account is unlocked.
+func (a *AppState) sessionSignPassword() (string, bool) {
+	if _, fieldName := a.argContainer.CurrentField(); fieldName != "SignPassword" {
+		return "", false
+	}
+
+	return a.controller.SessionPassword()
+}
+
+// isWatchOnlySign returns true if the current field is a sign passphrase, but the default account
+// is watch-only.
+func (a *AppState) isWatchOnlySign() bool {
+	if _, fieldName := a.argContainer.CurrentField(); fieldName != "SignPassword" {
+		return false
+	}
+
+	return a.controller.DefaultAccountWatchOnly()
 }
 
 func (a *AppState) executeInRoot(cmd string) {
@@ -218,7 +358,18 @@ func (a *AppState) executeInRoot(cmd string) {
 	if a.argContainer != nil {
 		fieldValue := a.argContainer.CurrentFieldValue()
 
-		if a.isCurrentFieldPassword() {
+		if a.isWatchOnlySign() {
+			if a.cmd != MenuTradeDerivativesMonitor {
+				logrus.WithError(ErrWatchOnly).Errorln("unable to sign with the default account")
+				a.DiscardCmd()
+				return
+			}
+
+			// positions are monitored without auto-reduce
+			cmd = ""
+		} else if password, ok := a.sessionSignPassword(); ok {
+			cmd = password
+		} else if a.isCurrentFieldPassword() {
 			line, err := terminal.ReadPassword(int(os.Stdin.Fd()))
 			if err != nil {
 				logrus.WithError(err).Warningln("failed to read input")
@@ -234,6 +385,16 @@ func (a *AppState) executeInRoot(cmd string) {
 		}
 
 		stop := a.argContainer.UpdateCurrentField(newFieldValue)
+		for !stop {
+			// skip sign passphrase while the account is unlocked
+			password, ok := a.sessionSignPassword()
+			if !ok {
+				break
+			}
+
+			stop = a.argContainer.UpdateCurrentField(password)
+		}
+
 		if !stop {
 			return
 		}
@@ -373,6 +534,10 @@ func (a *AppState) executeInRoot(cmd string) {
 					Text:        "1.00",
 					Description: "Price must be entered as float. Minimum value is 0.0000001",
 				}})
+				a.argContainer.AddSuggestions(3, []prompt.Suggest{{
+					Text:        "1",
+					Description: "Leverage must be entered as float. Minimum value is 1, used if left empty",
+				}})
 
 				return
 			case oneOf(MenuItem(cmd), MenuTradeDerivativesLimitShort, "h", "h/limitshort"):
@@ -389,8 +554,106 @@ func (a *AppState) executeInRoot(cmd string) {
 					Text:        "1.00",
 					Description: "Price must be entered as float. Minimum value is 0.0000001",
 				}})
+				a.argContainer.AddSuggestions(3, []prompt.Suggest{{
+					Text:        "1",
+					Description: "Leverage must be entered as float. Minimum value is 1, used if left empty",
+				}})
+
+				return
+			case oneOf(MenuItem(cmd), MenuTradeDerivativesFillOrder, "f", "f/fill"):
+				a.argContainer = NewArgContainer(&TradeDerivativeFillOrderArgs{})
+				a.cmd = MenuTradeDerivativesFillOrder
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestDerivativesMarkets())
+				a.argContainer.AddSuggestionsLazy(1, []int{0}, func(args ...interface{}) []prompt.Suggest {
+					return a.controller.SuggestDerivativeOrderToFill(args[0].(string))
+				})
+				a.argContainer.AddSuggestions(2, []prompt.Suggest{{
+					Text:        "1",
+					Description: "Quantity must be entered as positive integer. Minimum value is 1",
+				}})
+				a.argContainer.AddSuggestions(3, []prompt.Suggest{{
+					Text:        "1",
+					Description: "Leverage must be entered as float. Minimum value is 1, used if left empty",
+				}})
+
+				return
+			case oneOf(MenuItem(cmd), MenuTradeDerivativesCancel, "c", "c/cancel"):
+				a.argContainer = NewArgContainer(&TradeDerivativeCancelOrderArgs{})
+				a.cmd = MenuTradeDerivativesCancel
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestDerivativesMarkets())
+				a.argContainer.AddSuggestionsLazy(1, []int{0}, func(args ...interface{}) []prompt.Suggest {
+					return a.controller.SuggestDerivativeOrderToCancel(args[0].(string))
+				})
+
+				return
+			case oneOf(MenuItem(cmd), MenuTradeDerivativesCancelAll, "ca", "ca/cancelall"):
+				a.argContainer = NewArgContainer(&TradeDerivativeCancelAllArgs{})
+				a.cmd = MenuTradeDerivativesCancelAll
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestDerivativesMarkets())
+
+				return
+			case oneOf(MenuItem(cmd), MenuTradeDerivativesDeposit, "dp", "dp/deposit"):
+				a.argContainer = NewArgContainer(&DerivativesDepositArgs{})
+				a.cmd = MenuTradeDerivativesDeposit
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, []prompt.Suggest{{
+					Text:        "1.00",
+					Description: "Amount of collateral, up to the token decimals",
+				}})
+
+				return
+			case oneOf(MenuItem(cmd), MenuTradeDerivativesWithdraw, "w", "w/withdraw"):
+				a.argContainer = NewArgContainer(&DerivativesWithdrawArgs{})
+				a.cmd = MenuTradeDerivativesWithdraw
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, []prompt.Suggest{{
+					Text:        "1.00",
+					Description: "Amount of collateral, up to the token decimals",
+				}})
+
+				return
+			case oneOf(MenuItem(cmd), MenuTradeDerivativesClose, "x", "x/close"):
+				a.argContainer = NewArgContainer(&TradeDerivativeCloseArgs{})
+				a.cmd = MenuTradeDerivativesClose
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestDerivativesMarkets())
+				a.argContainer.AddSuggestions(1, []prompt.Suggest{{
+					Text:        "100",
+					Description: "Percent of position to close. Maximum value is 100",
+				}})
+				a.argContainer.AddSuggestions(2, []prompt.Suggest{{
+					Text:        "market",
+					Description: "Close against the best order in the orderbook",
+				}, {
+					Text:        "1.00",
+					Description: "Place an offsetting limit order at the price",
+				}})
+				a.argContainer.AddSuggestionsLazy(3, []int{0}, func(args ...interface{}) []prompt.Suggest {
+					return a.controller.SuggestPositionToClose(args[0].(string))
+				})
 
 				return
+			case oneOf(MenuItem(cmd), MenuTradeDerivativesMonitor, "mon", "mon/monitor"):
+				a.argContainer = NewArgContainer(&DerivativesMonitorArgs{})
+				a.cmd = MenuTradeDerivativesMonitor
+				a.suggestions = nil
+
+				return
+			case oneOf(MenuItem(cmd), MenuTradeDerivativesUnmonitor, "umon", "umon/unmonitor"):
+				a.cmd = MenuTradeDerivativesUnmonitor
+				a.suggestions = nil
+			case oneOf(MenuItem(cmd), MenuTradeDerivativesMarkets, "m", "m/markets"):
+				a.cmd = MenuTradeDerivativesMarkets
+				a.suggestions = nil
 			case oneOf(MenuItem(cmd), MenuTradeDerivativesOrderbook, "o", "o/orderbook"):
 				a.argContainer = NewArgContainer(&TradeDerivativeOrderbookArgs{})
 				a.cmd = MenuTradeDerivativesOrderbook
@@ -431,9 +694,153 @@ func (a *AppState) executeInRoot(cmd string) {
 				a.suggestions = nil
 
 				return
+			case oneOf(MenuItem(cmd), MenuAccountsMnemonic, "m", "m/mnemonic"):
+				a.argContainer = NewArgContainer(&ethcore.AccountMnemonicArgs{})
+				a.cmd = MenuAccountsMnemonic
+				a.suggestions = nil
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsHDImport, "h", "h/hdimport"):
+				a.argContainer = NewArgContainer(&ethcore.AccountImportMnemonicArgs{})
+				a.cmd = MenuAccountsHDImport
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestionsLazy(2, []int{0, 1}, func(args ...interface{}) []prompt.Suggest {
+					return a.controller.SuggestMnemonicAccounts(args[0].(string), args[1].(string))
+				})
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsLabel, "lb", "lb/label"):
+				a.argContainer = NewArgContainer(&AccountLabelArgs{})
+				a.cmd = MenuAccountsLabel
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestAccounts())
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsSet, "s", "s/set"):
+				a.argContainer = NewArgContainer(&AccountSetArgs{})
+				a.cmd = MenuAccountsSet
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestAccounts())
+				a.argContainer.AddSuggestions(1, a.controller.SuggestAccountSettings())
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsUnlock, "ul", "ul/unlock"):
+				a.argContainer = NewArgContainer(&AccountUnlockArgs{})
+				a.cmd = MenuAccountsUnlock
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, []prompt.Suggest{
+					{Text: "15m", Description: "Keep the account unlocked for 15 minutes."},
+					{Text: "1h", Description: "Keep the account unlocked for an hour."},
+				})
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsWatch, "w", "w/watch"):
+				a.argContainer = NewArgContainer(&AccountWatchArgs{})
+				a.cmd = MenuAccountsWatch
+				a.suggestions = nil
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsUnwatch, "uw", "uw/unwatch"):
+				a.argContainer = NewArgContainer(&AccountUnwatchArgs{})
+				a.cmd = MenuAccountsUnwatch
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestWatchOnlyAccounts())
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsSign, "sg", "sg/sign"):
+				a.argContainer = NewArgContainer(&ethcore.AccountSignArgs{})
+				a.cmd = MenuAccountsSign
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestMessageKinds())
+				a.argContainer.AddSuggestions(1, []prompt.Suggest{
+					{Text: "@~/message.json", Description: "Read the message or JSON from a file, or paste it inline."},
+				})
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsVerify, "v", "v/verify"):
+				a.argContainer = NewArgContainer(&ethcore.AccountVerifyArgs{})
+				a.cmd = MenuAccountsVerify
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestMessageKinds())
+				a.argContainer.AddSuggestions(1, []prompt.Suggest{
+					{Text: "@~/message.json", Description: "Read the message or JSON from a file, or paste it inline."},
+				})
+				a.argContainer.AddSuggestions(2, []prompt.Suggest{
+					{Text: "0x", Description: "Hex signature, leave empty to use the signature from the order JSON."},
+				})
+				a.argContainer.AddSuggestions(3, a.controller.SuggestAccounts())
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsAddPath, "ap", "ap/addpath"):
+				a.argContainer = NewArgContainer(&AccountPathArgs{})
+				a.cmd = MenuAccountsAddPath
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, []prompt.Suggest{
+					{Text: "~/keystore", Description: "Path of a dir containing keyfiles."},
+				})
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsRemovePath, "rp", "rp/rmpath"):
+				a.argContainer = NewArgContainer(&AccountPathArgs{})
+				a.cmd = MenuAccountsRemovePath
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestKeystorePaths())
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsActivity, "ac", "ac/activity"):
+				a.argContainer = NewArgContainer(&AccountActivityArgs{})
+				a.cmd = MenuAccountsActivity
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestAccounts())
+				a.argContainer.AddSuggestions(1, []prompt.Suggest{{
+					Text:        "10000",
+					Description: "Number of recent blocks to scan, used if left empty. Maximum value is 100000",
+				}})
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsLock, "lk", "lk/lock"):
+				a.cmd = MenuAccountsLock
+				a.suggestions = nil
 			case oneOf(MenuItem(cmd), MenuAccountsList, "l", "l/list"):
 				a.cmd = MenuAccountsList
 				a.suggestions = nil
+			case oneOf(MenuItem(cmd), MenuAccountsExport, "e", "e/export"):
+				a.argContainer = NewArgContainer(&ethcore.AccountExportArgs{})
+				a.cmd = MenuAccountsExport
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestAccounts())
+				a.argContainer.AddSuggestions(1, []prompt.Suggest{
+					{Text: "~/keyfile.json", Description: "Destination path for the encrypted keyfile."},
+					{Text: ethcore.ExportPrivateKey, Description: "Reveal the raw private key."},
+				})
+
+				return
+			case oneOf(MenuItem(cmd), MenuAccountsPasswd, "pw", "pw/passwd"):
+				a.argContainer = NewArgContainer(&ethcore.AccountPasswdArgs{})
+				a.cmd = MenuAccountsPasswd
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestAccounts())
+				a.argContainer.AddSuggestions(4, []prompt.Suggest{
+					{Text: "262144", Description: "Standard scrypt N (default)."},
+					{Text: "4096", Description: "Light scrypt N, faster but weaker."},
+				})
+				a.argContainer.AddSuggestions(5, []prompt.Suggest{
+					{Text: "1", Description: "Scrypt P (default)."},
+				})
+
+				return
 			default:
 				logrus.Warningf("unknown command: %s", cmd)
 				return
@@ -480,6 +887,80 @@ func (a *AppState) executeInRoot(cmd string) {
 					Description: "Amount must be entered as float. Minimum value is 0.0000001 WETH",
 				}})
 
+				return
+			case oneOf(MenuItem(cmd), MenuUtilSend, "s", "s/send"):
+				a.argContainer = NewArgContainer(&UtilSendArgs{})
+				a.cmd = MenuUtilSend
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestAccounts())
+				a.argContainer.AddSuggestions(1, []prompt.Suggest{{
+					Text:        "1.00",
+					Description: "Amount of ETH must be entered as float.",
+				}})
+
+				return
+			case oneOf(MenuItem(cmd), MenuUtilTransfer, "tr", "tr/transfer"):
+				a.argContainer = NewArgContainer(&UtilTransferArgs{})
+				a.cmd = MenuUtilTransfer
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestTokens())
+				a.argContainer.AddSuggestions(1, a.controller.SuggestAccounts())
+				a.argContainer.AddSuggestions(2, []prompt.Suggest{{
+					Text:        "1.00",
+					Description: "Amount of tokens must be entered as float.",
+				}})
+
+				return
+			case oneOf(MenuItem(cmd), MenuUtilAllowances, "al", "al/allowances"):
+				a.argContainer = NewArgContainer(&UtilAllowancesArgs{})
+				a.cmd = MenuUtilAllowances
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestSpenders())
+
+				return
+			case oneOf(MenuItem(cmd), MenuUtilAllowance, "a", "a/allowance"):
+				a.argContainer = NewArgContainer(&UtilAllowanceArgs{})
+				a.cmd = MenuUtilAllowance
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestTokens())
+				a.argContainer.AddSuggestions(1, a.controller.SuggestSpenders())
+				a.argContainer.AddSuggestions(2, []prompt.Suggest{{
+					Text:        "1.00",
+					Description: "Exact amount of tokens must be entered as float, 0 revokes the allowance.",
+				}})
+
+				return
+			case oneOf(MenuItem(cmd), MenuUtilRevoke, "rv", "rv/revoke"):
+				a.argContainer = NewArgContainer(&UtilRevokeArgs{})
+				a.cmd = MenuUtilRevoke
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, a.controller.SuggestTokens())
+				a.argContainer.AddSuggestions(1, a.controller.SuggestSpenders())
+
+				return
+			case oneOf(MenuItem(cmd), MenuUtilApprovals, "ap", "ap/approvals"):
+				a.argContainer = NewArgContainer(&UtilApprovalsArgs{})
+				a.cmd = MenuUtilApprovals
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, customTokensSuggestions)
+				a.argContainer.AddSuggestions(1, approvalBlocksSuggestions)
+
+				return
+			case oneOf(MenuItem(cmd), MenuUtilRevokeAll, "ra", "ra/revokeall"):
+				a.argContainer = NewArgContainer(&UtilRevokeAllArgs{})
+				a.cmd = MenuUtilRevokeAll
+				a.suggestions = nil
+
+				a.argContainer.AddSuggestions(0, customTokensSuggestions)
+				a.argContainer.AddSuggestions(1, a.controller.SuggestSpenders())
+				a.argContainer.AddSuggestions(2, approvalBlocksSuggestions)
+
 				return
 			default:
 				logrus.Warningf("unknown command: %s", cmd)
@@ -536,6 +1017,24 @@ func (a *AppState) executeCmd(root MenuItem, args interface{}) {
 			a.controller.ActionTradeDerivativesLimitLong(args)
 		case MenuTradeDerivativesLimitShort:
 			a.controller.ActionTradeDerivativesLimitShort(args)
+		case MenuTradeDerivativesFillOrder:
+			a.controller.ActionTradeDerivativesFillOrder(args)
+		case MenuTradeDerivativesCancel:
+			a.controller.ActionTradeDerivativesCancelOrder(args)
+		case MenuTradeDerivativesCancelAll:
+			a.controller.ActionTradeDerivativesCancelAll(args)
+		case MenuTradeDerivativesDeposit:
+			a.controller.ActionDerivativesDeposit(args)
+		case MenuTradeDerivativesWithdraw:
+			a.controller.ActionDerivativesWithdraw(args)
+		case MenuTradeDerivativesMarkets:
+			a.controller.ActionDerivativesMarkets()
+		case MenuTradeDerivativesClose:
+			a.controller.ActionTradeDerivativesClose(args)
+		case MenuTradeDerivativesMonitor:
+			a.controller.ActionDerivativesMonitorStart(args)
+		case MenuTradeDerivativesUnmonitor:
+			a.controller.ActionDerivativesMonitorStop()
 		}
 	case MenuAccounts:
 		switch a.cmd {
@@ -549,6 +1048,36 @@ func (a *AppState) executeCmd(root MenuItem, args interface{}) {
 			a.controller.ActionAccountsImportPrivKey(args)
 		case MenuAccountsList:
 			a.controller.ActionAccountsList()
+		case MenuAccountsMnemonic:
+			a.controller.ActionAccountsMnemonic(args)
+		case MenuAccountsLabel:
+			a.controller.ActionAccountsLabel(args)
+		case MenuAccountsUnlock:
+			a.controller.ActionAccountsUnlock(args)
+		case MenuAccountsLock:
+			a.controller.ActionAccountsLock()
+		case MenuAccountsWatch:
+			a.controller.ActionAccountsWatch(args)
+		case MenuAccountsSign:
+			a.controller.ActionAccountsSign(args)
+		case MenuAccountsAddPath:
+			a.controller.ActionAccountsAddPath(args)
+		case MenuAccountsRemovePath:
+			a.controller.ActionAccountsRemovePath(args)
+		case MenuAccountsActivity:
+			a.controller.ActionAccountsActivity(args)
+		case MenuAccountsVerify:
+			a.controller.ActionAccountsVerify(args)
+		case MenuAccountsUnwatch:
+			a.controller.ActionAccountsUnwatch(args)
+		case MenuAccountsSet:
+			a.controller.ActionAccountsSet(args)
+		case MenuAccountsHDImport:
+			a.controller.ActionAccountsImportMnemonic(args)
+		case MenuAccountsExport:
+			a.controller.ActionAccountsExport(args)
+		case MenuAccountsPasswd:
+			a.controller.ActionAccountsPasswd(args)
 		}
 	case MenuUtil:
 		switch a.cmd {
@@ -562,6 +1091,20 @@ func (a *AppState) executeCmd(root MenuItem, args interface{}) {
 			a.controller.ActionUtilWrap(args)
 		case MenuUtilUnwrap:
 			a.controller.ActionUtilUnwrap(args)
+		case MenuUtilSend:
+			a.controller.ActionUtilSend(args)
+		case MenuUtilTransfer:
+			a.controller.ActionUtilTransfer(args)
+		case MenuUtilAllowances:
+			a.controller.ActionUtilAllowances(args)
+		case MenuUtilAllowance:
+			a.controller.ActionUtilAllowance(args)
+		case MenuUtilRevoke:
+			a.controller.ActionUtilRevoke(args)
+		case MenuUtilApprovals:
+			a.controller.ActionUtilApprovals(args)
+		case MenuUtilRevokeAll:
+			a.controller.ActionUtilRevokeAll(args)
 		}
 
 		// case MenuDebug:
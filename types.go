@@ -7,8 +7,9 @@ import (
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/pkg/errors"
 
-	zeroex "github.com/InjectiveLabs/zeroex-go"
+	sdaAPI "github.com/InjectiveLabs/dexterm/gen/derivatives_api"
 	sraAPI "github.com/InjectiveLabs/dexterm/gen/relayer_api"
+	zeroex "github.com/InjectiveLabs/zeroex-go"
 )
 
 func ro2zo(o *sraAPI.Order) (*zeroex.SignedOrder, error) {
@@ -63,3 +64,13 @@ func ro2zo(o *sraAPI.Order) (*zeroex.SignedOrder, error) {
 	}
 	return signedOrder, nil
 }
+
+// do2zo converts derivative order from SDA API into zeroex.SignedOrder,
+// both order schemas share the same set of fields.
+func do2zo(o *sdaAPI.DerivativeOrder) (*zeroex.SignedOrder, error) {
+	if o == nil {
+		return nil, errors.New("derivative order is missing")
+	}
+
+	return ro2zo((*sraAPI.Order)(o))
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"strings"
+
+	prompt "github.com/c-bata/go-prompt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	signercore "github.com/ethereum/go-ethereum/signer/core"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethcore"
+	"github.com/InjectiveLabs/dexterm/ethereum/ethfw/keystore"
+)
+
+var ErrWatchOnly = errors.New("account is watch-only, signing is not possible")
+
+// watchOnlyGuard wraps a keystore and blocks any key access for watch-only accounts,
+// so no action is able to sign for them, regardless of the keystore backend.
+type watchOnlyGuard struct {
+	keystore.EthKeyStore
+
+	isWatchOnly func(account common.Address) bool
+}
+
+func newWatchOnlyGuard(ks keystore.EthKeyStore, isWatchOnly func(account common.Address) bool) keystore.EthKeyStore {
+	return &watchOnlyGuard{
+		EthKeyStore: ks,
+		isWatchOnly: isWatchOnly,
+	}
+}
+
+func (g *watchOnlyGuard) PrivateKey(account common.Address, password string) (*ecdsa.PrivateKey, bool) {
+	if g.isWatchOnly(account) {
+		return nil, false
+	}
+
+	return g.EthKeyStore.PrivateKey(account, password)
+}
+
+func (g *watchOnlyGuard) SignerFn(account common.Address, password string) bind.SignerFn {
+	if g.isWatchOnly(account) {
+		return func(types.Signer, common.Address, *types.Transaction) (*types.Transaction, error) {
+			return nil, ErrWatchOnly
+		}
+	}
+
+	return g.EthKeyStore.SignerFn(account, password)
+}
+
+func (g *watchOnlyGuard) SignHash(
+	account common.Address,
+	password string,
+	kind keystore.HashKind,
+	hash common.Hash,
+) ([]byte, error) {
+	if g.isWatchOnly(account) {
+		return nil, ErrWatchOnly
+	}
+
+	return g.EthKeyStore.SignHash(account, password, kind, hash)
+}
+
+func (g *watchOnlyGuard) SignTypedData(
+	account common.Address,
+	password string,
+	typedData signercore.TypedData,
+) ([]byte, error) {
+	if g.isWatchOnly(account) {
+		return nil, ErrWatchOnly
+	}
+
+	return g.EthKeyStore.SignTypedData(account, password, typedData)
+}
+
+func (g *watchOnlyGuard) SignText(account common.Address, password string, text []byte) ([]byte, error) {
+	if g.isWatchOnly(account) {
+		return nil, ErrWatchOnly
+	}
+
+	return g.EthKeyStore.SignText(account, password, text)
+}
+
+func (ctl *AppController) isWatchOnly(account common.Address) bool {
+	v, ok := ctl.accountSetting(account, accountSettingWatchOnly)
+	return ok && toBool(v)
+}
+
+// DefaultAccountWatchOnly returns true if the default account has no key and can't sign.
+func (ctl *AppController) DefaultAccountWatchOnly() bool {
+	defaultAccount, ok := ctl.getConfigValue("accounts.default")
+	if !ok {
+		return false
+	}
+
+	return ctl.isWatchOnly(common.HexToAddress(defaultAccount))
+}
+
+func (ctl *AppController) watchOnlyAccounts() []common.Address {
+	var accounts []common.Address
+	for _, key := range ctl.configKeys("accounts.settings") {
+		if !common.IsHexAddress(key) {
+			continue
+		}
+
+		if account := common.HexToAddress(key); ctl.isWatchOnly(account) {
+			accounts = append(accounts, account)
+		}
+	}
+
+	return accounts
+}
+
+// allAccounts lists the keystore accounts followed by watch-only ones.
+func (ctl *AppController) allAccounts() []common.Address {
+	return append(ctl.keystore.Accounts(), ctl.watchOnlyAccounts()...)
+}
+
+type AccountWatchArgs struct {
+	Address string
+	Label   string
+}
+
+func (ctl *AppController) ActionAccountsWatch(args interface{}) {
+	watchArgs := args.(*AccountWatchArgs)
+
+	addr, err := ctl.addWatchOnlyAccount(watchArgs.Address)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to add watch-only account")
+		return
+	}
+
+	if len(watchArgs.Label) > 0 {
+		ctl.ActionAccountsLabel(&AccountLabelArgs{
+			Address: addr.Hex(),
+			Label:   watchArgs.Label,
+		})
+	}
+
+	logrus.Infof("Added watch-only account %s", ctl.formatAccount(addr))
+}
+
+func (ctl *AppController) addWatchOnlyAccount(address string) (common.Address, error) {
+	addr, err := ethcore.ParseAccount(&ethcore.AccountUseArgs{
+		Address: strings.TrimSpace(address),
+	})
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	for _, acc := range ctl.keystore.Accounts() {
+		if acc == addr {
+			err := errors.Errorf("account %s has a key in keystore, can't be watch-only", addr.Hex())
+			return common.Address{}, err
+		}
+	}
+
+	if err := ctl.setAccountSetting(addr, accountSettingWatchOnly, "true"); err != nil {
+		return common.Address{}, err
+	}
+
+	return addr, nil
+}
+
+type AccountUnwatchArgs struct {
+	Address string
+}
+
+func (ctl *AppController) ActionAccountsUnwatch(args interface{}) {
+	addr, err := ctl.resolveAccount(args.(*AccountUnwatchArgs).Address)
+	if err != nil {
+		logrus.WithError(err).Errorln("failed to remove watch-only account")
+		return
+	} else if !ctl.isWatchOnly(addr) {
+		logrus.WithField("address", addr.Hex()).Errorln("account is not watch-only")
+		return
+	}
+
+	if err := ctl.setAccountSetting(addr, accountSettingWatchOnly, ""); err != nil {
+		logrus.WithError(err).Errorln("failed to remove watch-only account")
+		return
+	}
+
+	logrus.Infof("Removed watch-only account %s", ctl.formatAccount(addr))
+
+	if defaultAccount, _ := ctl.getConfigValue("accounts.default"); common.HexToAddress(defaultAccount) == addr {
+		logrus.Warningln("The default account has been removed, select another one with use")
+	}
+}
+
+func (ctl *AppController) SuggestWatchOnlyAccounts() []prompt.Suggest {
+	accounts := ctl.watchOnlyAccounts()
+	suggestions := make([]prompt.Suggest, len(accounts))
+
+	for i, addr := range accounts {
+		if label, ok := ctl.accountLabel(addr); ok {
+			suggestions[i].Text = label
+			suggestions[i].Description = addr.Hex()
+			continue
+		}
+
+		suggestions[i].Text = addr.Hex()
+	}
+
+	return suggestions
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/InjectiveLabs/dexterm/ethereum/ethcore"
+)
+
+var errWizardQuit = errors.New("account setup cancelled")
+
+// accountWizard sets up the default account on the first run, when the keystore is empty.
+type accountWizard struct {
+	ctl    *AppController
+	reader *bufio.Reader
+}
+
+type accountWizardOption struct {
+	Key         string
+	Name        string
+	Description string
+	Run         func() (common.Address, error)
+}
+
+func (ctl *AppController) runAccountWizard() error {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		err := errors.New("no accounts found in keystore, run dexterm in a terminal to set up an account, or use --insecure-dev-account on devnet")
+		return err
+	}
+
+	w := &accountWizard{
+		ctl:    ctl,
+		reader: bufio.NewReader(os.Stdin),
+	}
+
+	options := []accountWizardOption{
+		{"1", "create", "Create a new account protected by a passphrase.", w.create},
+		{"2", "keyfile", "Import an encrypted keyfile.", w.importKeyfile},
+		{"3", "privkey", "Import a private key.", w.importPrivKey},
+		{"4", "mnemonic", "Import the first account of a BIP39 mnemonic.", w.importMnemonic},
+		{"5", "watch", "Continue with a watch-only address, trading will be disabled.", w.watch},
+	}
+
+	fmt.Println("No accounts found in keystore, let's set up the default account.")
+
+	for {
+		fmt.Println()
+		for _, opt := range options {
+			fmt.Printf("  %s) %-9s %s\n", opt.Key, opt.Name, opt.Description)
+		}
+		fmt.Printf("  q) %-9s %s\n", "quit", "Quit without setting up an account.")
+
+		choice, err := w.readLine("Choice: ")
+		if err != nil {
+			return err
+		} else if choice == "q" || choice == "quit" {
+			return errWizardQuit
+		}
+
+		var selected *accountWizardOption
+		for i := range options {
+			if choice == options[i].Key || choice == options[i].Name {
+				selected = &options[i]
+				break
+			}
+		}
+
+		if selected == nil {
+			logrus.Warningf("Unknown choice: %s", choice)
+			continue
+		}
+
+		addr, err := selected.Run()
+		if err != nil {
+			logrus.WithError(err).Errorln("failed to set up account, try again")
+			continue
+		}
+
+		ctl.setConfigValue("accounts.default", addr.Hex())
+		logrus.Infof("Using the default account: %s", ctl.formatAccount(addr))
+
+		return nil
+	}
+}
+
+func (w *accountWizard) create() (common.Address, error) {
+	password, err := w.readNewPassphrase()
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	acc, err := ethcore.CreateAccount(w.ctl.keystorePath, &ethcore.AccountCreateArgs{
+		Password:       password,
+		PasswordRepeat: password,
+	})
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return acc.Address, nil
+}
+
+func (w *accountWizard) importKeyfile() (common.Address, error) {
+	path, err := w.readLine("Keyfile path: ")
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return ethcore.ImportAccount(w.ctl.keystorePath, &ethcore.AccountImportArgs{
+		FilePath: path,
+	})
+}
+
+func (w *accountWizard) importPrivKey() (common.Address, error) {
+	privKey, err := w.readSecret("Private key (hex): ")
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	password, err := w.readNewPassphrase()
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return ethcore.ImportPrivKey(w.ctl.keystorePath, &ethcore.AccountImportPrivKeyArgs{
+		PrivateKeyHex:  strings.TrimSpace(privKey),
+		Password:       password,
+		PasswordRepeat: password,
+	})
+}
+
+func (w *accountWizard) importMnemonic() (common.Address, error) {
+	mnemonic, err := w.readSecret("Mnemonic: ")
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	bip39Password, err := w.readSecret("BIP39 passphrase (empty if none): ")
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	password, err := w.readNewPassphrase()
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	addresses, err := ethcore.ImportMnemonic(w.ctl.keystorePath, &ethcore.AccountImportMnemonicArgs{
+		Mnemonic:       mnemonic,
+		Bip39Password:  bip39Password,
+		Indices:        "0",
+		Password:       password,
+		PasswordRepeat: password,
+	})
+	if err != nil {
+		return common.Address{}, err
+	} else if len(addresses) == 0 {
+		return common.Address{}, errors.New("account of the mnemonic is already in keystore")
+	}
+
+	return addresses[0], nil
+}
+
+func (w *accountWizard) watch() (common.Address, error) {
+	address, err := w.readLine("Address to watch: ")
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return w.ctl.addWatchOnlyAccount(address)
+}
+
+// readNewPassphrase asks for a passphrase twice and checks its strength.
+func (w *accountWizard) readNewPassphrase() (string, error) {
+	password, err := w.readSecret(fmt.Sprintf("New passphrase (at least %d symbols): ", ethcore.MinPassphraseLength))
+	if err != nil {
+		return "", err
+	} else if err := ethcore.CheckPassphraseStrength(password); err != nil {
+		return "", err
+	}
+
+	passwordRepeat, err := w.readSecret("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	} else if password != passwordRepeat {
+		return "", errors.New("passphrases don't match")
+	}
+
+	return password, nil
+}
+
+func (w *accountWizard) readLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+
+	line, err := w.reader.ReadString('\n')
+	if err != nil {
+		fmt.Println()
+		return "", errWizardQuit
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+func (w *accountWizard) readSecret(prompt string) (string, error) {
+	fmt.Print(prompt)
+
+	secret, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read input")
+	}
+
+	return string(secret), nil
+}